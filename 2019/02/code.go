@@ -0,0 +1,69 @@
+package main
+
+import (
+	"aoc-in-go/2019/intcode"
+	"aoc-in-go/ez"
+	"errors"
+
+	"github.com/jpillora/puzzler/harness/aoc"
+)
+
+func main() {
+	aoc.Harness(ez.Safe(run))
+}
+
+// on code change, run will be executed 4 times:
+// 1. with: false (part1), and example input
+// 2. with: true (part2), and example input
+// 3. with: false (part1), and user input
+// 4. with: true (part2), and user input
+// the return value of each run is printed to stdout
+func run(part2 bool, input string) any {
+	program, err := intcode.Parse(input)
+	if err != nil {
+		return err
+	}
+
+	// The example programs are too short to take a noun and verb, just run them as they are
+	if len(program) < 20 {
+		if part2 {
+			return "no example for part 2"
+		}
+		mem, err := Output(program, program[1], program[2])
+		if err != nil {
+			return err
+		}
+		return mem
+	}
+
+	// Part 2
+	if part2 {
+		for noun := 0; noun <= 99; noun++ {
+			for verb := 0; verb <= 99; verb++ {
+				out, err := Output(program, noun, verb)
+				if err == nil && out == 19690720 {
+					return 100*noun + verb
+				}
+			}
+		}
+		return errors.New("no noun and verb produce 19690720")
+	}
+
+	// Part 1, restore the "1202 program alarm" state
+	out, err := Output(program, 12, 2)
+	if err != nil {
+		return err
+	}
+	return out
+}
+
+// Output runs program with noun and verb at addresses 1 and 2, returning what is left at address 0
+func Output(program []int, noun, verb int) (int, error) {
+	vm := intcode.New(program)
+	vm.Mem[1] = noun
+	vm.Mem[2] = verb
+	if _, err := vm.Run(); err != nil {
+		return 0, err
+	}
+	return vm.Mem[0], nil
+}
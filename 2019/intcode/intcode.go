@@ -0,0 +1,160 @@
+// Package intcode is the Intcode computer shared by the 2019 puzzles
+// https://adventofcode.com/2019/day/9 has the complete spec
+package intcode
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// State is why Run returned
+type State int
+
+const (
+	// Halted means the program reached opcode 99
+	Halted State = iota
+	// NeedInput means the program is waiting on an input instruction, Push some values and Run again
+	NeedInput
+)
+
+// paramCount is how many parameters each opcode takes, indexed by opcode, 0 marks an unknown opcode
+var paramCount = [10]int{1: 3, 2: 3, 3: 1, 4: 1, 5: 2, 6: 2, 7: 3, 8: 3, 9: 1}
+
+// VM is an Intcode computer, memory grows as needed when addresses past the end of the program are used
+type VM struct {
+	Mem    []int
+	IP     int
+	Base   int
+	Input  []int
+	Output []int
+}
+
+// Parse reads a comma separated program
+func Parse(program string) ([]int, error) {
+	parts := strings.Split(strings.TrimSpace(program), ",")
+	out := make([]int, len(parts))
+	for i, part := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("intcode: position %d: %w", i, err)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// New creates a VM running a copy of program, so the same program can be loaded into several VMs
+func New(program []int) *VM {
+	mem := make([]int, len(program))
+	copy(mem, program)
+	return &VM{Mem: mem}
+}
+
+// Push queues input values for the program to read
+func (vm *VM) Push(values ...int) {
+	vm.Input = append(vm.Input, values...)
+}
+
+// Drain returns and clears everything the program has output so far
+func (vm *VM) Drain() []int {
+	out := vm.Output
+	vm.Output = nil
+	return out
+}
+
+// grow makes sure addr is within memory
+func (vm *VM) grow(addr int) {
+	if addr >= len(vm.Mem) {
+		vm.Mem = append(vm.Mem, make([]int, addr+1-len(vm.Mem))...)
+	}
+}
+
+// addr returns the address of parameter n (1 based) of the current instruction, following its mode
+func (vm *VM) addr(n int) (int, error) {
+	mode := vm.Mem[vm.IP] / 100
+	for i := 1; i < n; i++ {
+		mode /= 10
+	}
+	var a int
+	switch mode % 10 {
+	case 0: // position
+		vm.grow(vm.IP + n)
+		a = vm.Mem[vm.IP+n]
+	case 1: // immediate
+		a = vm.IP + n
+	case 2: // relative
+		vm.grow(vm.IP + n)
+		a = vm.Base + vm.Mem[vm.IP+n]
+	default:
+		return 0, fmt.Errorf("intcode: unknown parameter mode %d at %d", mode%10, vm.IP)
+	}
+	if a < 0 {
+		return 0, fmt.Errorf("intcode: negative address %d at %d", a, vm.IP)
+	}
+	vm.grow(a)
+	return a, nil
+}
+
+// Run executes until the program halts or needs input that hasn't been pushed yet
+func (vm *VM) Run() (State, error) {
+	for {
+		vm.grow(vm.IP)
+		op := vm.Mem[vm.IP] % 100
+		if op == 99 {
+			return Halted, nil
+		}
+
+		if op < 0 || op >= len(paramCount) || paramCount[op] == 0 {
+			return Halted, fmt.Errorf("intcode: unknown opcode %d at %d", op, vm.IP)
+		}
+
+		// Every instruction has at most 3 parameters, resolve the addresses of the ones this opcode uses
+		params := paramCount[op]
+		var p [4]int
+		for n := 1; n <= params; n++ {
+			a, err := vm.addr(n)
+			if err != nil {
+				return Halted, err
+			}
+			p[n] = a
+		}
+
+		next := vm.IP + params + 1
+		switch op {
+		case 1: // add
+			vm.Mem[p[3]] = vm.Mem[p[1]] + vm.Mem[p[2]]
+		case 2: // multiply
+			vm.Mem[p[3]] = vm.Mem[p[1]] * vm.Mem[p[2]]
+		case 3: // input
+			if len(vm.Input) == 0 {
+				return NeedInput, nil
+			}
+			vm.Mem[p[1]] = vm.Input[0]
+			vm.Input = vm.Input[1:]
+		case 4: // output
+			vm.Output = append(vm.Output, vm.Mem[p[1]])
+		case 5: // jump-if-true
+			if vm.Mem[p[1]] != 0 {
+				next = vm.Mem[p[2]]
+			}
+		case 6: // jump-if-false
+			if vm.Mem[p[1]] == 0 {
+				next = vm.Mem[p[2]]
+			}
+		case 7: // less than
+			vm.Mem[p[3]] = 0
+			if vm.Mem[p[1]] < vm.Mem[p[2]] {
+				vm.Mem[p[3]] = 1
+			}
+		case 8: // equals
+			vm.Mem[p[3]] = 0
+			if vm.Mem[p[1]] == vm.Mem[p[2]] {
+				vm.Mem[p[3]] = 1
+			}
+		case 9: // adjust relative base
+			vm.Base += vm.Mem[p[1]]
+		}
+		vm.IP = next
+	}
+}
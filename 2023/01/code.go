@@ -2,15 +2,13 @@ package main
 
 import (
 	"aoc-in-go/ez"
-	"bufio"
 	"fmt"
 	"github.com/jpillora/puzzler/harness/aoc"
-	"regexp"
 	"strings"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -20,47 +18,34 @@ func main() {
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
 func run(part2 bool, input string) any {
+	vocab := ez.Digits
+	if part2 {
+		// Spelled out numbers count too, the scanner handles word-reuse, e.g. eightwo where the end result should be 82
+		vocab = ez.Digits.Merge(ez.EnglishDigits)
+	}
+
 	sum := 0
-	sc := bufio.NewScanner(strings.NewReader(input))
-	for sc.Scan() {
-		line := sc.Text()
-		newLine := line
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	for i, line := range lines {
 		if len(line) == 0 {
 			continue
 		}
-		if part2 {
-			numWordsRx := regexp.MustCompile(`(one|two|three|four|five|six|seven|eight|nine)`)
-			words := map[string]string{
-				// Account for word-reuse, e.g. eightwo where the end result should be 82
-				"one":   "o1e",
-				"two":   "t2o",
-				"three": "t3e",
-				"four":  "4",
-				"five":  "5e",
-				"six":   "6",
-				"seven": "7n",
-				"eight": "e8t",
-				"nine":  "n9e",
-			}
-			for {
-				foundWord := numWordsRx.FindString(newLine)
-				newLine = strings.Replace(newLine, foundWord, words[foundWord], 1)
-				if foundWord == "" {
-					break
-				}
-			}
+		value, ok := Calibration(line, vocab)
+		if !ok {
+			return fmt.Errorf("line %d: no digits found in %q", i+1, line)
 		}
-
-		nonDigits := regexp.MustCompile(`\D`)
-		onlyNums := nonDigits.ReplaceAllString(newLine, "")
-
-		firstNum := onlyNums[0:1]
-		lastNum := onlyNums[len(onlyNums)-1:]
-		newNum := ez.Atoi(fmt.Sprintf("%s%s", firstNum, lastNum))
-		//fmt.Println(fmt.Sprintf("line: %s; newLine: %s; onlyNums: %s; newNum: %d", line, newLine, onlyNums, newNum))
-
-		sum += newNum
+		sum += value
 	}
 
 	return sum
 }
+
+// Calibration combines the first and last digit tokens of the line to form a two digit number
+func Calibration(line string, vocab ez.Vocabulary) (int, bool) {
+	first, ok := vocab.FirstToken(line)
+	if !ok {
+		return 0, false
+	}
+	last, _ := vocab.LastToken(line)
+	return first*10 + last, true
+}
@@ -2,14 +2,14 @@ package main
 
 import (
 	"aoc-in-go/ez"
-	"bufio"
+	"fmt"
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -18,101 +18,116 @@ func main() {
 // 3. with: false (part1), and user input
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
-func run(part2 bool, input string) any {
-	// when you're ready to do part 2, remove this "not implemented" block
-	if part2 {
-		sum := 0
-		sc := bufio.NewScanner(strings.NewReader(input))
-		for sc.Scan() {
-			line := sc.Text()
-			newLine := line
-			if len(line) == 0 {
-				continue
-			}
 
-			// Split on first :, which is Game XXX: ...
-			gameParts := strings.SplitN(newLine, ":", 2)
-			_, gamePulls := gameParts[0], gameParts[1]
-			// Initialize got's to 1, so multiplication with 0 doesn't cause issues
-			got := map[string]int{
-				"red":   1,
-				"green": 1,
-				"blue":  1,
-			}
+// Draw is a handful of cubes by colour, any colour name is allowed and missing colours count as 0
+type Draw map[string]int
 
-			// Split each game's pulls into invidiual pulls
-			pulls := strings.Split(gamePulls, ";")
-			for _, pull := range pulls {
-				for _, part := range strings.Split(pull, ",") {
-					pullParts := strings.Split(strings.TrimSpace(part), " ")
-					numPulled := ez.Atoi(pullParts[0])
-					colorPulled := pullParts[1]
-
-					// Only set the got if the numPulled is greater than the existing got
-					if numPulled > got[colorPulled] {
-						got[colorPulled] = numPulled
-					}
-				}
-			}
+// Bag is the cubes by colour a bag holds, missing colours count as 0
+type Bag map[string]int
 
-			sum += (got["red"] * got["green"] * got["blue"])
-		}
+// Game is a game ID and each handful of cubes drawn from the bag
+type Game struct {
+	ID    int
+	Draws []Draw
+}
 
+func run(part2 bool, input string) any {
+	games, err := ParseGames(input)
+	if err != nil {
+		return err
+	}
+
+	// Part 2
+	if part2 {
+		sum := 0
+		for _, game := range games {
+			sum += game.MinimumBag().Power("red", "green", "blue")
+		}
 		return sum
 	}
 
-	// solve part 1 here
-	desired := map[string]int{
+	// Part 1
+	bag := Bag{
 		"red":   12,
 		"green": 13,
 		"blue":  14,
 	}
-	// games := map[string]Game{}
 	sum := 0
-	sc := bufio.NewScanner(strings.NewReader(input))
-	for sc.Scan() {
-		line := sc.Text()
-		newLine := line
+	for _, game := range games {
+		if game.Possible(bag) {
+			sum += game.ID
+		}
+	}
+	return sum
+}
+
+// ParseGames reads lines like "Game 1: 3 blue, 4 red; 1 red, 2 green"
+func ParseGames(input string) ([]Game, error) {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	games := make([]Game, 0, len(lines))
+	for i, line := range lines {
 		if len(line) == 0 {
 			continue
 		}
 
 		// Split on first :, which is Game XXX: ...
-		gameParts := strings.SplitN(newLine, ":", 2)
-		gameRef, gamePulls := gameParts[0], gameParts[1]
-		// Get the game ID as an integer
-		gameID := ez.Atoi(strings.Split(gameRef, " ")[1])
-		gamePass := true
-
-		// Split each game's pulls into invidiual pulls
-		pulls := strings.Split(gamePulls, ";")
-		for _, pull := range pulls {
-			got := map[string]int{
-				"red":   0,
-				"green": 0,
-				"blue":  0,
-			}
-			for _, part := range strings.Split(pull, ",") {
-				// part example: 3 blue
-				pullParts := strings.Split(strings.TrimSpace(part), " ")
-				numPulled := ez.Atoi(pullParts[0])
-				colorPulled := pullParts[1]
+		gameRef, gameDraws, ok := strings.Cut(line, ":")
+		if !ok || !strings.HasPrefix(gameRef, "Game ") {
+			return nil, fmt.Errorf("line %d: not a game: %q", i+1, line)
+		}
+		game := Game{ID: ez.Atoi(strings.TrimPrefix(gameRef, "Game "))}
 
-				got[colorPulled] += numPulled
+		// Split each game's draws into individual draws
+		for _, draw := range strings.Split(gameDraws, ";") {
+			got := Draw{}
+			for _, part := range strings.Split(draw, ",") {
+				// part example: 3 blue
+				drawParts := strings.Fields(part)
+				if len(drawParts) != 2 {
+					return nil, fmt.Errorf("line %d: expected a count and colour, got %q", i+1, part)
+				}
+				got[drawParts[1]] += ez.Atoi(drawParts[0])
 			}
-			if got["red"] > desired["red"] ||
-				got["green"] > desired["green"] ||
-				got["blue"] > desired["blue"] {
-				// If any game got more than the desired max, fail the game
-				gamePass = false
-				// fmt.Println(fmt.Sprintf("Game %d failed: got %v", gameID, got))
+			game.Draws = append(game.Draws, got)
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+// Possible reports if every draw could have come from bag
+func (g Game) Possible(bag Bag) bool {
+	for _, draw := range g.Draws {
+		for color, count := range draw {
+			if count > bag[color] {
+				return false
 			}
 		}
+	}
+	return true
+}
 
-		if gamePass {
-			sum += gameID
+// MinimumBag is the fewest cubes of each colour the bag could have held to make every draw possible
+func (g Game) MinimumBag() Bag {
+	bag := Bag{}
+	for _, draw := range g.Draws {
+		for color, count := range draw {
+			bag[color] = max(bag[color], count)
 		}
 	}
+	return bag
+}
 
-	return sum
+// Power multiplies together the counts of the given colours, or of every colour in the bag when none are given
+func (b Bag) Power(colors ...string) int {
+	if len(colors) == 0 {
+		for color := range b {
+			colors = append(colors, color)
+		}
+	}
+	power := 1
+	for _, color := range colors {
+		power *= b[color]
+	}
+	return power
 }
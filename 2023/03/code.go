@@ -2,16 +2,13 @@ package main
 
 import (
 	"aoc-in-go/ez"
-	"bufio"
-	"regexp"
-	"slices"
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -20,132 +17,141 @@ func main() {
 // 3. with: false (part1), and user input
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
+
+// Number is a run of digits on a single row, Start and End are the columns it covers (End is exclusive)
+// Symbols holds the index of every adjacent symbol
+type Number struct {
+	Row     int
+	Start   int
+	End     int
+	Value   int
+	Symbols []int
+}
+
+// Symbol is any character that isn't a digit or a '.', Numbers holds the index of every adjacent number
+type Symbol struct {
+	Row     int
+	Col     int
+	Char    byte
+	Numbers []int
+}
+
+// Schematic is the engine schematic broken down into its numbers and symbols
+type Schematic struct {
+	Numbers []Number
+	Symbols []Symbol
+}
+
 func run(part2 bool, input string) any {
+	s := ParseSchematic(input)
+
 	// Part 2
 	if part2 {
-		sc := bufio.NewScanner(strings.NewReader(input))
-		symMap := map[int]map[int][]int{}
-		lineNo := 0
-		for sc.Scan() {
-			lineNo++
-			line := sc.Text()
-			newLine := line
-			if len(line) == 0 {
-				continue
-			}
+		sum := 0
+		// A gear is any * symbol that is adjacent to exactly two part numbers, multiply them together and add to the sum
+		for _, gear := range s.SymbolsWithNeighbours('*', 2) {
+			sum += s.Numbers[gear.Numbers[0]].Value * s.Numbers[gear.Numbers[1]].Value
+		}
+		return sum
+	}
 
-			// Find all * symbols
-			symMatch := regexp.MustCompile(`[\*]`)
-			for _, symMatches := range symMatch.FindAllStringIndex(newLine, -1) {
-				if len(symMatches) > 0 {
-					if symMap[lineNo] == nil {
-						symMap[lineNo] = map[int][]int{}
-					}
-					// Store symbol location by line number and location (column)
-					// Eventually will add adjacent numbers to the slice of ints
-					symMap[lineNo][symMatches[0]] = []int{}
+	// Part 1
+	sum := 0
+	for _, num := range s.PartNumbers() {
+		sum += num.Value
+	}
+	return sum
+}
+
+// ParseSchematic reads the schematic in a single pass, recording which number owns each digit cell so
+// symbols can then look around themselves to find their adjacent numbers
+func ParseSchematic(input string) *Schematic {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	s := &Schematic{}
+	owner := make([][]int, len(lines))
+
+	for i, line := range lines {
+		owner[i] = make([]int, len(line))
+		for j := 0; j < len(line); j++ {
+			owner[i][j] = -1
+			char := line[j]
+			switch {
+			case char >= '0' && char <= '9':
+				// Extend the number to the left if there is one, otherwise start a new one
+				if j > 0 && owner[i][j-1] != -1 {
+					owner[i][j] = owner[i][j-1]
+					s.Numbers[owner[i][j]].End = j + 1
+				} else {
+					owner[i][j] = len(s.Numbers)
+					s.Numbers = append(s.Numbers, Number{Row: i, Start: j, End: j + 1})
 				}
+			case char != '.':
+				s.Symbols = append(s.Symbols, Symbol{Row: i, Col: j, Char: char})
 			}
 		}
+	}
 
-		sc = bufio.NewScanner(strings.NewReader(input))
-		lineNo = 0
-		sum := 0
-		for sc.Scan() {
-			lineNo++
-			line := sc.Text()
-			newLine := line
-			if len(line) == 0 {
+	for i := range s.Numbers {
+		num := &s.Numbers[i]
+		num.Value = ez.Atoi(lines[num.Row][num.Start:num.End])
+	}
+
+	// Look around each symbol, the same number can be seen from several cells so only link it once
+	for si := range s.Symbols {
+		sym := &s.Symbols[si]
+		for i := sym.Row - 1; i <= sym.Row+1; i++ {
+			if i < 0 || i >= len(owner) {
 				continue
 			}
-
-			// Find numbers
-			numMatch := regexp.MustCompile(`[\d]+`)
-			for _, numMatches := range numMatch.FindAllStringIndex(newLine, -1) {
-				// Given the location of the digits "look around" to the adjacent locations
-				for i := lineNo - 1; i <= lineNo+1; i++ {
-					for j := numMatches[0] - 1; j <= numMatches[1]; j++ {
-						// If the location appears in the symMap, it's adjacent to a symbol
-						if _, rowExists := symMap[i]; rowExists {
-							if _, colExists := symMap[i][j]; colExists {
-								// Append the number to the symbol it's adjacent to
-								num := ez.Atoi(newLine[numMatches[0]:numMatches[1]])
-								symMap[i][j] = append(symMap[i][j], num)
-							}
-						}
-					}
+			prev := -1
+			for j := sym.Col - 1; j <= sym.Col+1; j++ {
+				if j < 0 || j >= len(owner[i]) || owner[i][j] == -1 {
+					prev = -1
+					continue
 				}
-			}
-		}
-
-		// Loop through the symMap
-		for _, rows := range symMap {
-			for _, vals := range rows {
-				// When exactly 2 numbers are adjacent to a symbol, multiply them together and add to the sum
-				if len(vals) == 2 {
-					sum += (vals[0] * vals[1])
+				// Still the number just linked, keep prev so its remaining digits don't link it again
+				if owner[i][j] == prev {
+					continue
 				}
+				prev = owner[i][j]
+				sym.Numbers = append(sym.Numbers, prev)
+				s.Numbers[prev].Symbols = append(s.Numbers[prev].Symbols, si)
 			}
 		}
-
-		return sum
 	}
 
-	// Part 1
-	sc := bufio.NewScanner(strings.NewReader(input))
-	symMap := map[int][]int{}
-	lineNo := 0
-	for sc.Scan() {
-		lineNo++
-		line := sc.Text()
-		newLine := line
-		if len(line) == 0 {
-			continue
-		}
+	return s
+}
 
-		// Grab all the symbols and store their locations in symMap
-		symMatch := regexp.MustCompile(`[^\d\.\s]`)
-		for _, symMatches := range symMatch.FindAllStringIndex(newLine, -1) {
-			if len(symMatches) > 0 {
-				symMap[lineNo] = append(symMap[lineNo], symMatches[0])
-			}
+// PartNumbers returns every number adjacent to at least one symbol
+func (s *Schematic) PartNumbers() []Number {
+	out := []Number{}
+	for _, num := range s.Numbers {
+		if len(num.Symbols) > 0 {
+			out = append(out, num)
 		}
 	}
+	return out
+}
 
-	sc = bufio.NewScanner(strings.NewReader(input))
-	lineNo = 0
-	sum := 0
-	for sc.Scan() {
-		lineNo++
-		line := sc.Text()
-		newLine := line
-		if len(line) == 0 {
-			continue
+// Isolated returns every number not adjacent to any symbol
+func (s *Schematic) Isolated() []Number {
+	out := []Number{}
+	for _, num := range s.Numbers {
+		if len(num.Symbols) == 0 {
+			out = append(out, num)
 		}
+	}
+	return out
+}
 
-		// Match consecutive digis
-		numMatch := regexp.MustCompile(`[\d]+`)
-		for _, numMatches := range numMatch.FindAllStringIndex(newLine, -1) {
-			numPasses := false
-			// Given the location of the digits "look around" to the adjacent locations
-			for i := lineNo - 1; i <= lineNo+1; i++ {
-				for j := numMatches[0] - 1; j <= numMatches[1]; j++ {
-					// If the location appears in the symMap, it's adjacent to a symbol
-					if _, rowExists := symMap[i]; rowExists {
-						if slices.Contains(symMap[i], j) {
-							numPasses = true
-						}
-					}
-				}
-			}
-
-			// The number has an adjacent symbol; convert the digits to an int and add to the sum
-			if numPasses {
-				num := ez.Atoi(newLine[numMatches[0]:numMatches[1]])
-				sum += num
-			}
+// SymbolsWithNeighbours returns the symbols matching char with exactly n adjacent numbers, a char of 0 matches any symbol
+func (s *Schematic) SymbolsWithNeighbours(char byte, n int) []Symbol {
+	out := []Symbol{}
+	for _, sym := range s.Symbols {
+		if (char == 0 || sym.Char == char) && len(sym.Numbers) == n {
+			out = append(out, sym)
 		}
 	}
-
-	return sum
+	return out
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+const example = `467..114..
+...*......
+..35..633.
+......#...
+617*......
+.....+.58.
+..592.....
+......755.
+...$.*....
+.664.598..`
+
+func TestRunExample(t *testing.T) {
+	if got := run(false, example); got != 4361 {
+		t.Errorf("part 1 = %v, want 4361", got)
+	}
+	if got := run(true, example); got != 467835 {
+		t.Errorf("part 2 = %v, want 467835", got)
+	}
+}
+
+// A number centred above or below a symbol touches it through all 3 of its digits, it must still only link once
+func TestNumberLinkedOnce(t *testing.T) {
+	s := ParseSchematic(".123.\n..*..\n...4.")
+	if got := s.Symbols[0].Numbers; !slices.Equal(got, []int{0, 1}) {
+		t.Errorf("symbol numbers = %v, want [0 1]", got)
+	}
+	for i, num := range s.Numbers {
+		if !slices.Equal(num.Symbols, []int{0}) {
+			t.Errorf("number %d symbols = %v, want [0]", i, num.Symbols)
+		}
+	}
+	if got := run(true, ".123.\n..*..\n...4."); got != 492 {
+		t.Errorf("part 2 = %v, want 492", got)
+	}
+}
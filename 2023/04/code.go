@@ -1,7 +1,8 @@
 package main
 
 import (
-	"bufio"
+	"aoc-in-go/ez"
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -10,7 +11,7 @@ import (
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -19,77 +20,81 @@ func main() {
 // 3. with: false (part1), and user input
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
+
+// Card is a single scratchcard, Winning are the numbers left of the | and Held are the numbers right of it
+type Card struct {
+	ID      int
+	Winning []int
+	Held    []int
+}
+
+var reCard = regexp.MustCompile(`^Card\s+(\d+):([\d\s]*)\|([\d\s]*)$`)
+
 func run(part2 bool, input string) any {
-	// Regex for input-example
-	// re := regexp.MustCompile(`Card\s+\d+:\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+\|\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)`)
-	// Regex for input-user
-	re := regexp.MustCompile(`Card\s+\d+:\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+\|\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)`)
+	cards, err := ParseCards(input)
+	if err != nil {
+		return err
+	}
 
 	// Part 2
 	if part2 {
-		sc := bufio.NewScanner(strings.NewReader(input))
-		lineNo := 0
-		// Copies is indexed by line number, and will hold the count of copies that line number has
-		copies := map[int]int{}
-		sum := 0
-		for sc.Scan() {
-			lineNo++
-			line := sc.Text()
-			newLine := line
-			if len(line) == 0 {
-				continue
-			}
-
-			matches := re.FindAllStringSubmatch(newLine, -1)
-			for _, match := range matches {
-				winners := match[1:11]
-				cards := match[11:]
-				// Intersect is a copy of all cards that exist within the winners set
-				intersect := lo.Intersect(winners, cards)
-				// Increment the copies of future card sets given the original winner count
-				for i := 1; i <= len(intersect); i++ {
-					copies[lineNo+i] += 1
-				}
-				// Using any copies of the current original, increment the copies of future card sets given the original winner count
-				for j := 1; j <= copies[lineNo]; j++ {
-					for i := 1; i <= len(intersect); i++ {
-						copies[lineNo+i] += 1
-					}
-				}
-			}
-			// Add the original + any copies to the total count
-			sum += 1 + copies[lineNo]
-		}
-
-		return sum
+		// Add the original + any copies to the total count
+		return ez.Sum(Copies(cards))
 	}
 
 	// Part 1
-	sc := bufio.NewScanner(strings.NewReader(input))
 	sum := 0
-	for sc.Scan() {
-		line := sc.Text()
-		newLine := line
+	for _, card := range cards {
+		sum += card.Score()
+	}
+	return sum
+}
+
+// ParseCards reads one card per line, any number of winning and held numbers are allowed
+func ParseCards(input string) ([]Card, error) {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	cards := make([]Card, 0, len(lines))
+	for i, line := range lines {
 		if len(line) == 0 {
 			continue
 		}
-
-		matches := re.FindAllStringSubmatch(newLine, -1)
-		for _, match := range matches {
-			winners := match[1:11]
-			cards := match[11:]
-			// Intersect is a copy of all cards that exist within the winners set
-			intersect := lo.Intersect(winners, cards)
-			if len(intersect) > 0 {
-				count := 1
-				// Double for all winning cards after the first
-				for i := 2; i <= len(intersect); i++ {
-					count *= 2
-				}
-				sum += count
-			}
+		match := reCard.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line %d: not a card: %q", i+1, line)
 		}
+		cards = append(cards, Card{
+			ID:      ez.Atoi(match[1]),
+			Winning: lo.Map(strings.Fields(match[2]), func(item string, _ int) int { return ez.Atoi(item) }),
+			Held:    lo.Map(strings.Fields(match[3]), func(item string, _ int) int { return ez.Atoi(item) }),
+		})
 	}
+	return cards, nil
+}
 
-	return sum
+// Matches is how many of the held numbers are winning numbers
+func (c Card) Matches() int {
+	return len(lo.Intersect(c.Winning, c.Held))
+}
+
+// Score is 1 point for the first match, doubled for every match after it
+func (c Card) Score() int {
+	if m := c.Matches(); m > 0 {
+		return 1 << (m - 1)
+	}
+	return 0
+}
+
+// Copies returns how many of each card you end up with, the original included, in the same order as cards
+// Each match wins one copy of the following cards, for every copy of the winning card held
+func Copies(cards []Card) []int {
+	copies := make([]int, len(cards))
+	for i := range copies {
+		copies[i] = 1
+	}
+	for i, card := range cards {
+		for j := i + 1; j <= i+card.Matches() && j < len(cards); j++ {
+			copies[j] += copies[i]
+		}
+	}
+	return copies
 }
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"aoc-in-go/ez"
+	"fmt"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -8,7 +12,7 @@ import (
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -18,107 +22,267 @@ func main() {
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
 func run(part2 bool, input string) any {
-	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
-	var seedToSoil, soilToFert, fertToWater, waterToLight, lightToTemp, tempToHum, humToLoc []Boundary
-	if len(lines) < 50 {
-		// Example file
-		seedToSoil = Boundaries(lines[3:5])
-		soilToFert = Boundaries(lines[7:10])
-		fertToWater = Boundaries(lines[12:16])
-		waterToLight = Boundaries(lines[18:20])
-		lightToTemp = Boundaries(lines[22:25])
-		tempToHum = Boundaries(lines[27:29])
-		humToLoc = Boundaries(lines[31:33])
-	} else {
-		seedToSoil = Boundaries(lines[3:15])
-		soilToFert = Boundaries(lines[17:46])
-		fertToWater = Boundaries(lines[48:65])
-		waterToLight = Boundaries(lines[67:85])
-		lightToTemp = Boundaries(lines[87:121])
-		tempToHum = Boundaries(lines[123:158])
-		humToLoc = Boundaries(lines[160:172])
-	}
-
-	var seeds []string
-	lowestLoc := int64(9999999999999999)
+	almanac, err := ParseAlmanac(input)
+	if err != nil {
+		return err
+	}
+	pipeline, err := almanac.Pipeline("seed", "location")
+	if err != nil {
+		return err
+	}
+
 	if part2 {
-		seedDefs := strings.Split(lines[0][7:], " ")
-		for i := 0; i < len(seedDefs); i += 2 {
-			seedStart := Atoi(seedDefs[i])
-			for j := int64(0); j <= Atoi(seedDefs[i+1]); j++ {
-				seed := seedStart + j
-				soil, soilSkip := Next(seed, seedToSoil)
-				fert, fertSkip := Next(soil, soilToFert)
-				water, waterSkip := Next(fert, fertToWater)
-				light, lightSkip := Next(water, waterToLight)
-				temp, tempSkip := Next(light, lightToTemp)
-				hum, humSkip := Next(temp, tempToHum)
-				loc, locSkip := Next(hum, humToLoc)
-				if loc < lowestLoc {
-					lowestLoc = loc
-				}
-				// Skip allows us to "jump" ahead an amount of seeds where incrementing by 1 would just produce a location that is 1 more
-				// When j++ is evaluated, the location should be significantly different as the increment will change one or more of the Boundaries that are matched in Next
-				j += j + max(min(soilSkip, fertSkip, waterSkip, lightSkip, tempSkip, humSkip, locSkip)-1, 0)
-			}
+		// Seeds are pairs of start and length, map the whole ranges at once and take the lowest start
+		ranges := []Range{}
+		for i := 0; i+1 < len(almanac.Seeds); i += 2 {
+			ranges = append(ranges, Range{Start: almanac.Seeds[i], End: almanac.Seeds[i] + almanac.Seeds[i+1]})
 		}
-	} else {
-		seeds = strings.Split(lines[0][7:], " ")
-		for _, seedStr := range seeds {
-			seed := Atoi(seedStr)
-			soil, _ := Next(seed, seedToSoil)
-			fert, _ := Next(soil, soilToFert)
-			water, _ := Next(fert, fertToWater)
-			light, _ := Next(water, waterToLight)
-			temp, _ := Next(light, lightToTemp)
-			hum, _ := Next(temp, tempToHum)
-			loc, _ := Next(hum, humToLoc)
-			if loc < lowestLoc {
-				lowestLoc = loc
+		lowestLoc := int64(-1)
+		for _, r := range pipeline.MapRanges(ranges) {
+			if lowestLoc == -1 || r.Start < lowestLoc {
+				lowestLoc = r.Start
 			}
 		}
+		return lowestLoc
 	}
 
+	lowestLoc := int64(-1)
+	for _, seed := range almanac.Seeds {
+		if loc := pipeline.Map(seed); lowestLoc == -1 || loc < lowestLoc {
+			lowestLoc = loc
+		}
+	}
 	return lowestLoc
 }
 
+// Limit is the exclusive upper bound of every category, mappings are filled out with identity boundaries up to it
+const Limit = int64(1) << 62
+
+// Range is a half open range of values, Start is included and End is not
+type Range struct {
+	Start int64
+	End   int64
+}
+
+// Boundary maps the source values LeftMin up to (not including) LeftMax onto the destination values starting at Right
 type Boundary struct {
 	LeftMin int64
 	LeftMax int64
 	Right   int64
 }
 
-func Boundaries(lines []string) []Boundary {
-	out := []Boundary{}
-	for _, line := range lines {
-		parts := strings.Split(line, " ")
-		// Note: parts are destination/rgt THEN source/lft, and finally the distance/range
-		rgt, lft, dis := parts[0], parts[1], parts[2]
-		out = append(out, Boundary{
-			LeftMin: Atoi(lft),
-			LeftMax: Atoi(lft) + Atoi(dis),
-			Right:   Atoi(rgt),
-		})
+// Mapping converts values of the From category to the To category
+// Boundaries are sorted and cover every value from 0 to Limit, values the almanac doesn't list map to themselves
+type Mapping struct {
+	From       string
+	To         string
+	Boundaries []Boundary
+}
+
+// Pipeline is a chain of mappings where each mapping's To is the next one's From
+type Pipeline []Mapping
+
+// Almanac is the parsed puzzle input
+type Almanac struct {
+	Seeds []int64
+	Maps  []Mapping
+}
+
+var reHeader = regexp.MustCompile(`^(\w+)-to-(\w+) map:$`)
+
+// ParseAlmanac reads the seeds line, then each "X-to-Y map:" section, in whatever order and however many there are
+func ParseAlmanac(input string) (*Almanac, error) {
+	sections := strings.Split(strings.TrimSpace(strings.ReplaceAll(input, "\r\n", "\n")), "\n\n")
+	a := &Almanac{}
+	for _, seed := range strings.Fields(strings.TrimPrefix(sections[0], "seeds:")) {
+		a.Seeds = append(a.Seeds, Atoi(seed))
+	}
+
+	for _, section := range sections[1:] {
+		lines := strings.Split(section, "\n")
+		header := reHeader.FindStringSubmatch(lines[0])
+		if header == nil {
+			return nil, fmt.Errorf("expected an X-to-Y map: header, got %q", lines[0])
+		}
+		boundaries := []Boundary{}
+		for _, line := range lines[1:] {
+			parts := strings.Fields(line)
+			if len(parts) != 3 {
+				return nil, fmt.Errorf("%s-to-%s map: expected 3 numbers, got %q", header[1], header[2], line)
+			}
+			// Note: parts are destination/rgt THEN source/lft, and finally the distance/range
+			rgt, lft, dis := Atoi(parts[0]), Atoi(parts[1]), Atoi(parts[2])
+			boundaries = append(boundaries, Boundary{LeftMin: lft, LeftMax: lft + dis, Right: rgt})
+		}
+		m, err := NewMapping(header[1], header[2], boundaries)
+		if err != nil {
+			return nil, err
+		}
+		a.Maps = append(a.Maps, m)
+	}
+
+	return a, nil
+}
+
+// NewMapping sorts the boundaries and fills the gaps between them with identity boundaries
+func NewMapping(from, to string, boundaries []Boundary) (Mapping, error) {
+	sorted := slices.Clone(boundaries)
+	slices.SortFunc(sorted, func(a, b Boundary) int {
+		return cmpInt64(a.LeftMin, b.LeftMin)
+	})
+
+	m := Mapping{From: from, To: to}
+	next := int64(0)
+	for _, b := range sorted {
+		if b.LeftMin < next {
+			return Mapping{}, fmt.Errorf("%s-to-%s map: source ranges overlap at %d", from, to, b.LeftMin)
+		}
+		if b.LeftMin > next {
+			m.Boundaries = append(m.Boundaries, Boundary{LeftMin: next, LeftMax: b.LeftMin, Right: next})
+		}
+		if b.LeftMax > b.LeftMin {
+			m.Boundaries = append(m.Boundaries, b)
+		}
+		next = b.LeftMax
+	}
+	if next < Limit {
+		m.Boundaries = append(m.Boundaries, Boundary{LeftMin: next, LeftMax: Limit, Right: next})
+	}
+	return m, nil
+}
+
+// Map provides the destination value for a single source value
+func (m Mapping) Map(in int64) int64 {
+	i, _ := slices.BinarySearchFunc(m.Boundaries, in, func(b Boundary, v int64) int {
+		if b.LeftMax <= v {
+			return -1
+		}
+		if b.LeftMin > v {
+			return 1
+		}
+		return 0
+	})
+	if i == len(m.Boundaries) {
+		return in
+	}
+	b := m.Boundaries[i]
+	return b.Right + in - b.LeftMin
+}
+
+// MapRanges maps every value in ranges, splitting a range wherever it crosses a boundary
+func (m Mapping) MapRanges(ranges []Range) []Range {
+	out := []Range{}
+	for _, r := range ranges {
+		for _, b := range m.Boundaries {
+			start, end := max(r.Start, b.LeftMin), min(r.End, b.LeftMax)
+			if start < end {
+				out = append(out, Range{Start: b.Right + start - b.LeftMin, End: b.Right + end - b.LeftMin})
+			}
+		}
 	}
 	return out
 }
 
-// Next provides the location of the destination/rgt given an in/source/rgt
-// Additionally, a "skip" is returned, which is the remaining range within the boundary
-func Next(in int64, boundaries []Boundary) (int64, int64) {
-	for _, b := range boundaries {
-		// If within the boundaries of the source/lft
-		if in >= b.LeftMin && in < b.LeftMax {
-			// Compute the difference, so we can add it to the destination/rgt
-			diff := in - b.LeftMin
-			// Skip is the remaining "range" that is just incrementally increasing by 1
-			skip := b.LeftMax - in
-			return b.Right + diff, skip
+// Compose returns a single mapping equivalent to applying m and then next
+func (m Mapping) Compose(next Mapping) Mapping {
+	out := Mapping{From: m.From, To: next.To}
+	for _, b := range m.Boundaries {
+		// Split each boundary's destination over next's boundaries
+		for _, nb := range next.Boundaries {
+			start, end := max(b.Right, nb.LeftMin), min(b.Right+b.LeftMax-b.LeftMin, nb.LeftMax)
+			if start < end {
+				out.Boundaries = append(out.Boundaries, Boundary{
+					LeftMin: b.LeftMin + start - b.Right,
+					LeftMax: b.LeftMin + end - b.Right,
+					Right:   nb.Right + start - nb.LeftMin,
+				})
+			}
 		}
 	}
+	slices.SortFunc(out.Boundaries, func(a, b Boundary) int {
+		return cmpInt64(a.LeftMin, b.LeftMin)
+	})
+	return out
+}
 
-	// If not found, the source and destination are the same
-	return in, 0
+// Invert returns the mapping from To back to From
+// This is only possible when no two source values map to the same destination, otherwise an error is returned
+func (m Mapping) Invert() (Mapping, error) {
+	inverted := make([]Boundary, len(m.Boundaries))
+	for i, b := range m.Boundaries {
+		inverted[i] = Boundary{LeftMin: b.Right, LeftMax: b.Right + b.LeftMax - b.LeftMin, Right: b.LeftMin}
+	}
+	out, err := NewMapping(m.To, m.From, inverted)
+	if err != nil {
+		return Mapping{}, fmt.Errorf("%s-to-%s map is not one-to-one: %w", m.From, m.To, err)
+	}
+	return out, nil
+}
+
+// Pipeline chains the almanac's mappings from one category to another by following each To to the next From
+func (a *Almanac) Pipeline(from, to string) (Pipeline, error) {
+	byFrom := map[string]Mapping{}
+	for _, m := range a.Maps {
+		byFrom[m.From] = m
+	}
+	p := Pipeline{}
+	for cur := from; cur != to; {
+		m, ok := byFrom[cur]
+		if !ok || len(p) > len(a.Maps) {
+			return nil, fmt.Errorf("no chain of maps from %s to %s", from, to)
+		}
+		p = append(p, m)
+		cur = m.To
+	}
+	return p, nil
+}
+
+// Map passes a single value through every mapping
+func (p Pipeline) Map(in int64) int64 {
+	for _, m := range p {
+		in = m.Map(in)
+	}
+	return in
+}
+
+// MapRanges passes ranges through every mapping
+func (p Pipeline) MapRanges(ranges []Range) []Range {
+	for _, m := range p {
+		ranges = m.MapRanges(ranges)
+	}
+	return ranges
+}
+
+// Compose flattens the pipeline into a single mapping
+func (p Pipeline) Compose() Mapping {
+	out := p[0]
+	for _, m := range p[1:] {
+		out = out.Compose(m)
+	}
+	return out
+}
+
+// Invert returns the pipeline running the other way, eg. location to seed
+func (p Pipeline) Invert() (Pipeline, error) {
+	out := make(Pipeline, len(p))
+	for i, m := range p {
+		inv, err := m.Invert()
+		if err != nil {
+			return nil, err
+		}
+		out[len(p)-1-i] = inv
+	}
+	return out, nil
+}
+
+func cmpInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
 }
 
 // Atoi ignores the errors in strconv.Atoi and returns the response
@@ -3,12 +3,13 @@ package main
 import (
 	"aoc-in-go/ez"
 	"github.com/jpillora/puzzler/harness/aoc"
+	"math/big"
 	"regexp"
 	"strings"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -25,27 +26,73 @@ func run(part2 bool, input string) any {
 
 	if part2 {
 		// For part 2, just collapse all the times & distances down to a single value
+		// Big ints mean the concatenated values can be as long as they like
 		times = []string{strings.Join(times, "")}
 		dists = []string{strings.Join(dists, "")}
 	}
 
-	out := 1
+	out := big.NewInt(1)
 	for raceNo, timeStr := range times {
-		beats := 0
-		time := ez.Atoi(timeStr)
-		dist := ez.Atoi(dists[raceNo])
-
-		// holdTime is how the button is held, but is also the "speed" of the boat
-		for holdTime := 1; holdTime <= time; holdTime++ {
-			// moveTime represents how much time is left in the race
-			moveTime := time - holdTime
-			// holdTime*moveTime is the total distance covered by the boat for the race
-			if holdTime*moveTime > dist {
-				beats++
-			}
-		}
-		out *= beats
+		time, _ := new(big.Int).SetString(timeStr, 10)
+		dist, _ := new(big.Int).SetString(dists[raceNo], 10)
+		out.Mul(out, WaysToWin(time, dist))
 	}
 
 	return out
 }
+
+// WaysToWin counts the hold times that travel further than dist in a race lasting time
+// Holding for h covers h*(time-h), so the winning hold times lie strictly between the roots of h^2 - time*h + dist = 0.
+// The integer square root gets within one of the lower root, which is then nudged onto the first winning hold time
+// without ever going through floats, the winners are symmetric so the last is time minus the first
+func WaysToWin(time, dist *big.Int) *big.Int {
+	// beats reports if holding for h wins the race
+	beats := func(h *big.Int) bool {
+		moved := new(big.Int).Sub(time, h)
+		moved.Mul(moved, h)
+		return moved.Cmp(dist) > 0
+	}
+
+	// disc = time^2 - 4*dist, no real roots means no hold time ever wins
+	disc := new(big.Int).Mul(time, time)
+	disc.Sub(disc, new(big.Int).Lsh(dist, 2))
+	if disc.Sign() < 0 {
+		return big.NewInt(0)
+	}
+
+	one := big.NewInt(1)
+	first := new(big.Int).Sub(time, new(big.Int).Sqrt(disc))
+	first.Rsh(first, 1)
+	for first.Sign() > 0 && beats(new(big.Int).Sub(first, one)) {
+		first.Sub(first, one)
+	}
+	// Past the midpoint with no winner means none win, e.g. dist == time^2/4 where the best hold only ties
+	last := new(big.Int)
+	for {
+		last.Sub(time, first)
+		if last.Cmp(first) < 0 {
+			return big.NewInt(0)
+		}
+		if beats(first) {
+			break
+		}
+		first.Add(first, one)
+	}
+	return last.Sub(last, first).Add(last, one)
+}
+
+// WaysToWinBrute tries every hold time in turn, it's only practical for part 1 sized races
+// but is kept as an oracle to check WaysToWin against
+func WaysToWinBrute(time, dist int) int {
+	beats := 0
+	// holdTime is how the button is held, but is also the "speed" of the boat
+	for holdTime := 1; holdTime <= time; holdTime++ {
+		// moveTime represents how much time is left in the race
+		moveTime := time - holdTime
+		// holdTime*moveTime is the total distance covered by the boat for the race
+		if holdTime*moveTime > dist {
+			beats++
+		}
+	}
+	return beats
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+// The best hold of time/2 only ties dist, nothing wins and that must be found without stepping towards time
+func TestWaysToWinTieIsClosedForm(t *testing.T) {
+	time, _ := new(big.Int).SetString("200000000000000000000", 10)
+	dist := new(big.Int).Mul(time, time)
+	dist.Rsh(dist, 2)
+	if got := WaysToWin(time, dist); got.Sign() != 0 {
+		t.Errorf("WaysToWin(%v, %v) = %v, want 0", time, dist, got)
+	}
+}
+
+// WaysToWin must agree with trying every hold time, including when nothing wins and when disc == 0
+func TestWaysToWinMatchesBrute(t *testing.T) {
+	for time := 0; time <= 60; time++ {
+		// time^2/4 is the furthest a hold can go, a little past it covers the no-winner cases
+		for dist := 0; dist <= time*time/4+2; dist++ {
+			want := WaysToWinBrute(time, dist)
+			got := WaysToWin(big.NewInt(int64(time)), big.NewInt(int64(dist)))
+			if !got.IsInt64() || got.Int64() != int64(want) {
+				t.Errorf("WaysToWin(%d, %d) = %v, want %d", time, dist, got, want)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"aoc-in-go/ez"
+	"fmt"
 	"github.com/jpillora/puzzler/harness/aoc"
 	"golang.org/x/exp/maps"
 	"regexp"
@@ -9,7 +10,7 @@ import (
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -23,8 +24,8 @@ func run(part2 bool, input string) any {
 	steps := strings.TrimSpace(lines[0])
 	network := map[string]Node{}
 	networkParser := regexp.MustCompile(`(\w+) = \((\w+), (\w+)\)`)
-	for _, v := range lines[2:] {
-		v := v
+	for i, v := range lines[2:] {
+		ez.SetLine(i+3, v)
 		parts := networkParser.FindAllStringSubmatch(v, -1)
 		if len(parts) == 0 {
 			continue
@@ -35,6 +36,10 @@ func run(part2 bool, input string) any {
 		}
 	}
 
+	if err := ez.CheckAssumptions(Assumptions(steps, network, part2)...); err != nil {
+		return err
+	}
+
 	// Part 2
 	if part2 {
 		// Find all paths starting with A
@@ -76,6 +81,9 @@ func run(part2 bool, input string) any {
 		}
 
 		// The least common multiple of all steps will be when all paths step will end in Z
+		if len(pathSteps) == 1 {
+			return pathSteps[0]
+		}
 		return ez.LCM(pathSteps[0], pathSteps[1], pathSteps[2:]...)
 	}
 
@@ -109,3 +117,80 @@ type Node struct {
 	L string
 	R string
 }
+
+// Assumptions are the properties of the input the solution relies on, checked before solving
+// Part 2 only gives the right answer when each A path loops through a single Z, reaching it again after the same
+// number of steps it took to get there in the first place, that is what makes the LCM of the first arrivals correct
+func Assumptions(steps string, network map[string]Node, part2 bool) []ez.Assumption {
+	assumptions := []ez.Assumption{
+		ez.Assume("steps are only L and R", func() error {
+			return ez.Expectf(len(steps) > 0 && strings.Trim(steps, "LR") == "", "steps are %q", steps)
+		}),
+		ez.Assume("every node leads to a known node", func() error {
+			for label, node := range network {
+				for _, next := range []string{node.L, node.R} {
+					if _, ok := network[next]; !ok {
+						return fmt.Errorf("%s leads to unknown node %s", label, next)
+					}
+				}
+			}
+			return nil
+		}),
+	}
+
+	if !part2 {
+		return append(assumptions, ez.Assume("AAA and ZZZ exist", func() error {
+			_, a := network["AAA"]
+			_, z := network["ZZZ"]
+			return ez.Expectf(a && z, "AAA found: %v, ZZZ found: %v", a, z)
+		}))
+	}
+
+	// Walking more than every (node, step) state without finding a Z means the path never will
+	limit := len(network)*len(steps) + 1
+	endsIn := func(label string, suffix byte) bool {
+		return label[len(label)-1] == suffix
+	}
+	return append(assumptions,
+		ez.Assume("there is at least one node ending in A", func() error {
+			for label := range network {
+				if endsIn(label, 'A') {
+					return nil
+				}
+			}
+			return fmt.Errorf("no start nodes")
+		}),
+		ez.Assume("each A path cycles through a single Z, returning to it after as many steps as it took to get there", func() error {
+			for label := range network {
+				if !endsIn(label, 'A') {
+					continue
+				}
+				first, z := walkToZ(steps, network, label, 0, limit)
+				if first == 0 {
+					return fmt.Errorf("%s never reaches a node ending in Z", label)
+				}
+				again, next := walkToZ(steps, network, z, first, limit)
+				if next != z || again != first {
+					return fmt.Errorf("%s reaches %s after %d steps, then %s after %d more", label, z, first, next, again)
+				}
+			}
+			return nil
+		}),
+	)
+}
+
+// walkToZ follows steps from label, starting at step index taken, until a node ending in Z
+// It returns how many steps it took and the node reached, or 0 steps if none was reached within limit
+func walkToZ(steps string, network map[string]Node, label string, taken, limit int) (int, string) {
+	for n := 1; n <= limit; n++ {
+		if steps[(taken+n-1)%len(steps)] == 'L' {
+			label = network[label].L
+		} else {
+			label = network[label].R
+		}
+		if label[len(label)-1] == 'Z' {
+			return n, label
+		}
+	}
+	return 0, ""
+}
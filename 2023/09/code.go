@@ -2,6 +2,8 @@ package main
 
 import (
 	"aoc-in-go/ez"
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
@@ -9,7 +11,7 @@ import (
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -22,53 +24,90 @@ func run(part2 bool, input string) any {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
 
 	predictions := []int{}
-	for _, line := range lines {
+	for i, line := range lines {
 		// Convert line parts to a slice of ints
-		vals := lo.Map(strings.Split(line, " "), func(item string, _ int) int {
+		vals := lo.Map(strings.Fields(line), func(item string, _ int) int {
 			return ez.Atoi(item)
 		})
-		diffs := make([][]int, 0)
-		// Line is our base [0], slice in our diffs
-		diffs = append(diffs, vals)
 
-		i := 1
-		for {
-			results := []int{}
-			for j := 0; j < len(diffs[i-1])-1; j++ {
-				// Each result in the new diff set is the difference between the next item (j+1) and the current item (j) from the previous row
-				// Example, where "i" is row 2 being generated from the previous row (row 1)
-				// row    j    j+1
-				// 1      10   15
-				// 2      5
-				res := diffs[i-1][j+1] - diffs[i-1][j]
-				results = append(results, res)
-			}
-			diffs = append(diffs, results)
+		predict := PredictNext
+		if part2 {
+			predict = PredictPrevious
+		}
+		next, err := predict(vals, 1)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		predictions = append(predictions, next[0])
+	}
+
+	return ez.Sum(predictions)
+}
 
-			// Break if newest diff set is all 0s
-			if lo.Every([]int{0}, diffs[i]) {
-				break
-			}
+// Analysis is the difference table of a sequence
+// Diffs[0] is the sequence itself, each row after is the differences between neighbours in the row above
+type Analysis struct {
+	Diffs [][]int
+	// Degree is the degree of the polynomial that generates the sequence, it is only meaningful when Polynomial is true
+	Degree int
+	// Polynomial is true when a row of all 0s was reached within the sequence's length, proving the row above it is constant
+	Polynomial bool
+}
 
-			i++
+// Analyze builds the difference table until a row is all 0s, or there are no more differences to take
+// A row of a single non-zero value is not enough to say the sequence is polynomial, there is nothing to show it's constant
+func Analyze(seq []int) Analysis {
+	a := Analysis{Diffs: [][]int{seq}}
+	for row := seq; len(row) > 0; {
+		if lo.EveryBy(row, func(v int) bool { return v == 0 }) {
+			a.Degree = max(len(a.Diffs)-2, 0)
+			a.Polynomial = true
+			break
+		}
+		// Each result in the new diff set is the difference between the next item (j+1) and the current item (j) from the previous row
+		next := make([]int, len(row)-1)
+		for j := range next {
+			next[j] = row[j+1] - row[j]
 		}
+		a.Diffs = append(a.Diffs, next)
+		row = next
+	}
+	return a
+}
 
-		newPrediction := 0
-		// Work from the next-to-last (before the all 0s line), to the original line
-		for n := len(diffs) - 1; n >= 0; n-- {
-			if part2 {
-				// Part 2 uses the first item, and must compute the difference
-				first := diffs[n][0]
-				newPrediction = first - newPrediction
-			} else {
-				// Part 1 uses the last item and is computed using summation
-				last, _ := lo.Last(diffs[n])
-				newPrediction += last
-			}
+// Next extends the difference table by k values, working from the constant row back up to the sequence
+func (a Analysis) Next(k int) []int {
+	// Last value of every row down to the all 0s row, which stays 0
+	lasts := make([]int, len(a.Diffs))
+	for i, row := range a.Diffs {
+		if len(row) > 0 {
+			lasts[i] = row[len(row)-1]
+		}
+	}
+	out := make([]int, k)
+	for n := 0; n < k; n++ {
+		for i := len(lasts) - 2; i >= 0; i-- {
+			lasts[i] += lasts[i+1]
 		}
+		out[n] = lasts[0]
+	}
+	return out
+}
 
-		predictions = append(predictions, newPrediction)
+// PredictNext returns the next k values of seq
+// An error is returned when the sequence isn't a polynomial within its length, as any prediction would just be a guess
+func PredictNext(seq []int, k int) ([]int, error) {
+	a := Analyze(seq)
+	if !a.Polynomial {
+		return nil, fmt.Errorf("differences of %v never reach a row of 0s, it is not a polynomial within its length", seq)
 	}
+	return a.Next(k), nil
+}
 
-	return ez.Sum(predictions)
+// PredictPrevious returns the k values before seq, nearest first
+// Reversing a polynomial sequence gives another polynomial of the same degree, so this is just PredictNext backwards
+func PredictPrevious(seq []int, k int) ([]int, error) {
+	reversed := slices.Clone(seq)
+	slices.Reverse(reversed)
+	return PredictNext(reversed, k)
 }
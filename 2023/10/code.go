@@ -2,19 +2,64 @@ package main
 
 import (
 	"aoc-in-go/ez"
+	"fmt"
 	"math"
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
-	"github.com/samber/lo"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
-type Pipe struct {
-	Dir []string
+// Directions are bit flags so a pipe can be stored as the set of directions it connects
+const (
+	North uint8 = 1 << iota
+	East
+	South
+	West
+)
+
+// Pipes maps each pipe to the two directions it connects
+var Pipes = map[byte]uint8{
+	'|': North | South,
+	'-': East | West,
+	'L': North | East,
+	'J': North | West,
+	'7': South | West,
+	'F': South | East,
+}
+
+// Box maps each pipe to its box drawing character for rendering
+var Box = map[byte]string{
+	'|': "│",
+	'-': "─",
+	'L': "└",
+	'J': "┘",
+	'7': "┐",
+	'F': "┌",
+}
+
+// Class is what a tile turned out to be once the loop is known
+type Class int
+
+const (
+	Outside Class = iota
+	Inside
+	Loop
+)
+
+type Point struct {
+	R int
+	C int
+}
+
+// Maze is the field of pipes, with S replaced in Tiles by the pipe it must be
+type Maze struct {
+	Tiles     [][]byte
+	Start     Point
+	StartPipe byte
 }
 
 // on code change, run will be executed 4 times:
@@ -24,87 +69,193 @@ type Pipe struct {
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
 func run(part2 bool, input string) any {
+	m, err := ParseMaze(input)
+	if err != nil {
+		return err
+	}
+	loop, err := m.Loop()
+	if err != nil {
+		return err
+	}
+
+	// Part 2
+	if part2 {
+		inside, err := m.CountInside(loop)
+		if err != nil {
+			return err
+		}
+		return inside
+	}
+
+	// Part 1 asks for the furthest from the start, which is just half of the total number of locations along the loop
+	return len(loop) / 2
+}
+
+// ParseMaze reads the tiles and works out which pipe S is from the neighbours that connect back to it
+func ParseMaze(input string) (*Maze, error) {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
-	// Pipe mapping, denoting the two directions a given pip connects
-	pipes := map[string]Pipe{
-		"|": {Dir: []string{"N", "S"}},
-		"-": {Dir: []string{"E", "W"}},
-		"L": {Dir: []string{"N", "E"}},
-		"J": {Dir: []string{"N", "W"}},
-		"7": {Dir: []string{"S", "W"}},
-		"F": {Dir: []string{"S", "E"}},
-	}
-	// Locs will represent a stored point location on the path
-	var locs []ez.Point
-
-	// Find start
-	var startRow, startCol int
+	m := &Maze{Tiles: make([][]byte, len(lines))}
 	for i, line := range lines {
-		if startCol = strings.Index(line, "S"); startCol != -1 {
-			startRow = i
-			break
+		m.Tiles[i] = []byte(line)
+		if j := strings.IndexByte(line, 'S'); j != -1 {
+			m.Start = Point{R: i, C: j}
 		}
 	}
-	locs = append(locs, ez.Point{X: float64(startCol), Y: float64(startRow)})
-
-	// Find a connected piece
-	move := ""
-	switch {
-	// Look East
-	case lo.Contains([]string{"7", "J", "-"}, string(lines[startRow][startCol+1])):
-		move = "E"
-	// Look West
-	case lo.Contains([]string{"F", "L", "-"}, string(lines[startRow][startCol-1])):
-		move = "W"
-	// Look North
-	case lo.Contains([]string{"7", "F", "|"}, string(lines[startRow-1][startCol])):
-		move = "N"
-	// Look South
-	case lo.Contains([]string{"L", "J", "|"}, string(lines[startRow+1][startCol])):
-		move = "N"
-	}
-
-	// canComeFrom is a simple map for used to prevent the pipe navigator from tracking backwards
-	canComeFrom := map[string]string{
-		"W": "E",
-		"E": "W",
-		"N": "S",
-		"S": "N",
-	}
-
-	// Begin moving
-	row, col := startRow, startCol
-	for {
-		// Prevent moving backwards
-		cameFrom := canComeFrom[move]
-		switch move {
-		case "N":
-			row--
-		case "S":
-			row++
-		case "E":
-			col++
-		case "W":
-			col--
+
+	connects := uint8(0)
+	for _, dir := range []uint8{North, East, South, West} {
+		n := Step(m.Start, dir)
+		if m.InBounds(n) && Pipes[m.Tiles[n.R][n.C]]&Opposite(dir) != 0 {
+			connects |= dir
 		}
-		pipeVal := string(lines[row][col])
-		if pipeVal != "S" {
-			nextMove := lo.Without(pipes[pipeVal].Dir, cameFrom)
-			move = nextMove[0]
+	}
+	for pipe, dirs := range Pipes {
+		if dirs == connects {
+			m.StartPipe = pipe
 		}
-		locs = append(locs, ez.Point{X: float64(col), Y: float64(row)})
+	}
+	if m.StartPipe == 0 {
+		return nil, fmt.Errorf("start at %d,%d does not connect to exactly two pipes", m.Start.R, m.Start.C)
+	}
+	m.Tiles[m.Start.R][m.Start.C] = m.StartPipe
+
+	return m, nil
+}
+
+// InBounds reports if p is within the maze
+func (m *Maze) InBounds(p Point) bool {
+	return p.R >= 0 && p.R < len(m.Tiles) && p.C >= 0 && p.C < len(m.Tiles[p.R])
+}
 
-		if row == startRow && col == startCol {
-			break
+// Loop follows the pipes from the start until it returns there, giving each tile of the loop in order starting with S
+// A pipe that doesn't connect back the way we came, or leads off the maze, means the loop is broken and an error is returned
+func (m *Maze) Loop() ([]Point, error) {
+	tiles := 0
+	for _, row := range m.Tiles {
+		tiles += len(row)
+	}
+
+	loop := []Point{m.Start}
+	// Leave by the lowest direction S connects, and never go back the way we came
+	dirs := Pipes[m.StartPipe]
+	dir := dirs & -dirs
+	p := Step(m.Start, dir)
+	for p != m.Start {
+		if !m.InBounds(p) {
+			return nil, fmt.Errorf("loop leaves the maze at %d,%d", p.R, p.C)
+		}
+		pipe := Pipes[m.Tiles[p.R][p.C]]
+		if pipe&Opposite(dir) == 0 {
+			return nil, fmt.Errorf("loop is broken at %d,%d, %q does not connect back", p.R, p.C, m.Tiles[p.R][p.C])
+		}
+		// Every tile can only be on the loop once, any longer and we're going round something other than S
+		if len(loop) >= tiles {
+			return nil, fmt.Errorf("loop runs longer than the %d tiles in the maze without returning to the start", tiles)
 		}
+		loop = append(loop, p)
+		dir = pipe &^ Opposite(dir)
+		p = Step(p, dir)
 	}
+	return loop, nil
+}
 
-	// Part 2
-	if part2 {
-		// Use Shoelace formula and Pick's theorem to generate the inner boundary points
-		return ez.Picks(math.Abs(ez.Shoelace(locs)), len(locs))
+// Classify marks every tile as part of the loop, inside it, or outside it
+// Scanning each row left to right, we're inside after crossing an odd number of loop tiles that connect north.
+// Only counting the north half of corners means a run like L--7 is one crossing and L--J is none
+func (m *Maze) Classify(loop []Point) [][]Class {
+	classes := make([][]Class, len(m.Tiles))
+	for i := range m.Tiles {
+		classes[i] = make([]Class, len(m.Tiles[i]))
+	}
+	for _, p := range loop {
+		classes[p.R][p.C] = Loop
+	}
+
+	for i := range m.Tiles {
+		inside := false
+		for j := range m.Tiles[i] {
+			if classes[i][j] == Loop {
+				if Pipes[m.Tiles[i][j]]&North != 0 {
+					inside = !inside
+				}
+				continue
+			}
+			if inside {
+				classes[i][j] = Inside
+			}
+		}
+	}
+	return classes
+}
+
+// CountInside counts the tiles enclosed by the loop, cross checking the scanline parity of Classify against
+// the Shoelace formula and Pick's theorem
+func (m *Maze) CountInside(loop []Point) (int, error) {
+	scanline := 0
+	for _, row := range m.Classify(loop) {
+		for _, c := range row {
+			if c == Inside {
+				scanline++
+			}
+		}
+	}
+
+	points := make([]ez.Point, len(loop))
+	for i, p := range loop {
+		points[i] = ez.Point{X: float64(p.C), Y: float64(p.R)}
+	}
+	shoelace := int(ez.Picks(math.Abs(ez.Shoelace(points)), len(loop)))
+
+	if scanline != shoelace {
+		return 0, fmt.Errorf("inside counts disagree: scanline parity found %d, shoelace found %d", scanline, shoelace)
+	}
+	return scanline, nil
+}
+
+// Render draws the loop with box drawing characters, inside tiles as I, and outside tiles as O
+func (m *Maze) Render(classes [][]Class) string {
+	lines := make([]string, len(m.Tiles))
+	for i := range m.Tiles {
+		sb := strings.Builder{}
+		for j, class := range classes[i] {
+			switch class {
+			case Loop:
+				sb.WriteString(Box[m.Tiles[i][j]])
+			case Inside:
+				sb.WriteString("I")
+			default:
+				sb.WriteString("O")
+			}
+		}
+		lines[i] = sb.String()
 	}
+	return strings.Join(lines, "\n")
+}
 
-	// Part 1 asks for the furthest from the start, which is just half of the total number of locations along the path
-	return len(locs) / 2
+// Step returns the point next to p in the given direction
+func Step(p Point, dir uint8) Point {
+	switch dir {
+	case North:
+		p.R--
+	case East:
+		p.C++
+	case South:
+		p.R++
+	case West:
+		p.C--
+	}
+	return p
+}
+
+// Opposite returns the direction facing back the other way
+func Opposite(dir uint8) uint8 {
+	switch dir {
+	case North:
+		return South
+	case East:
+		return West
+	case South:
+		return North
+	}
+	return East
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+)
+
+const example = `..F7.
+.FJ|.
+SJ.L7
+|F--J
+LJ...`
+
+const example2 = `.F----7F7F7F7F-7....
+.|F--7||||||||FJ....
+.||.FJ||||||||L7....
+FJL7L7LJLJ||LJ.L-7..
+L--J.L7...LJS7F-7L7.
+....F-J..F7FJ|L7L7L7
+....L7.F7||L7|.L7L7|
+.....|FJLJ|FJ|F7|.LJ
+....FJL-7.||.||||...
+....L---J.LJ.LJLJ...`
+
+func TestRunExample(t *testing.T) {
+	if got := run(false, example); got != 8 {
+		t.Errorf("part 1 = %v, want 8", got)
+	}
+	if got := run(true, example2); got != 8 {
+		t.Errorf("part 2 = %v, want 8", got)
+	}
+}
+
+// A loop that doesn't join back up is an error, rather than walking forever
+func TestLoopBroken(t *testing.T) {
+	for _, input := range []string{
+		// The | can't be entered from the west
+		"S-|\n|..\nL..",
+		// The - leads straight off the edge
+		"S-\n|.",
+	} {
+		m, err := ParseMaze(input)
+		if err != nil {
+			t.Fatalf("ParseMaze(%q) error: %v", input, err)
+		}
+		if loop, err := m.Loop(); err == nil {
+			t.Errorf("Loop() of %q = %v, want an error", input, loop)
+		}
+	}
+}
@@ -2,15 +2,13 @@ package main
 
 import (
 	"aoc-in-go/ez"
-	"math"
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
-	"github.com/samber/lo"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -21,67 +19,41 @@ func main() {
 // the return value of each run is printed to stdout
 func run(part2 bool, input string) any {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
-	repeat := 1
+	factor := 2
 	if part2 {
-		// Since the "repeated" row replaces the existing row, we still end up counting the existing row
-		// So, we subtract the desired repeat by 1
-		repeat = 999999
+		factor = 1000000
 	}
 
-	// Mark rows and columns that need to be considered repeated
-	repeatRows := []int{}
-	for i, line := range lines {
-		if !strings.Contains(line, "#") {
-			repeatRows = append(repeatRows, i)
-		}
-	}
-
-	repeatCols := []int{}
-	for i := len(lines[0]) - 1; i >= 0; i-- {
-		expandCol := true
-		for _, line := range lines {
-			if string(line[i]) == "#" {
-				expandCol = false
-			}
-		}
-		if expandCol {
-			repeatCols = append(repeatCols, i)
-		}
-	}
+	return GalaxyDistances(lines, factor)
+}
 
-	// Chart the points, using column/j/X and row/i/Y
-	var points []ez.Point
+// GalaxyDistances sums the distance between every pair of galaxies once each empty row and column has grown to factor rows or columns
+func GalaxyDistances(lines []string, factor int) int {
+	// Mark rows and columns that have a galaxy in them, anything unmarked expands
+	rowUsed := make([]bool, len(lines))
+	colUsed := make([]bool, len(lines[0]))
 	for i, line := range lines {
 		for j, c := range line {
 			if c == '#' {
-				points = append(points, ez.Point{X: float64(j), Y: float64(i)})
+				rowUsed[i] = true
+				colUsed[j] = true
 			}
 		}
 	}
+	rowMap := ez.ExpansionMap(rowUsed, factor)
+	colMap := ez.ExpansionMap(colUsed, factor)
 
-	sum := .0
-	for i, p1 := range points {
-		for j, p2 := range points {
-			// We only need unique pairs, which will only be points that are greater than the existing point
-			if j > i {
-				// Repeats are those rows or columns that pass over one of the rows or columns that are marked as repeating
-				xDist := math.Abs(p1.X - p2.X)
-				xRepeats := lo.Filter(repeatCols, func(item, index int) bool {
-					a := max(p1.X, p2.X)
-					b := min(p1.X, p2.X)
-					return float64(item) < a && float64(item) > b
-				})
-				yDist := math.Abs(p1.Y - p2.Y)
-				yRepeats := lo.Filter(repeatRows, func(item, index int) bool {
-					a := max(p1.Y, p2.Y)
-					b := min(p1.Y, p2.Y)
-					return float64(item) < a && float64(item) > b
-				})
-				// Sum up the distances and add on any repeating rows/columns
-				sum += xDist + yDist + float64((len(xRepeats)+len(yRepeats))*repeat)
+	// Chart the expanded points, using column/j/X and row/i/Y
+	xs := []int{}
+	ys := []int{}
+	for i, line := range lines {
+		for j, c := range line {
+			if c == '#' {
+				xs = append(xs, colMap[j])
+				ys = append(ys, rowMap[i])
 			}
 		}
 	}
 
-	return int64(sum)
+	return ez.SumManhattanDistances(xs, ys)
 }
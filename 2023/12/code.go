@@ -9,7 +9,7 @@ import (
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -18,117 +18,142 @@ func main() {
 // 3. with: false (part1), and user input
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
+
+// Record is a row of springs and the sizes of its contiguous groups of damaged springs
+type Record struct {
+	Pattern string
+	Groups  []int
+}
+
 func run(part2 bool, input string) any {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
 	sum := int64(0)
-	for _, line := range lines {
-		parts := strings.Split(line, " ")
-		pattern := parts[0]
-		setsStr := parts[1]
-
+	for i, line := range lines {
+		ez.SetLine(i+1, line)
+		record := ParseRecord(line)
 		if part2 {
 			// Part 2
-			pattern = strings.Join(Dupe(pattern, 5), "?")
-			setsStr = strings.Join(Dupe(setsStr, 5), ",")
+			record = record.Unfold(5)
 		}
-
-		setsStrs := strings.Split(setsStr, ",")
-		sets := lo.Map(setsStrs, func(item string, _ int) int {
-			return ez.Atoi(item)
-		})
-
-		sum += int64(ProcessPattern(pattern, sets))
+		sum += int64(record.Count())
 	}
 
 	return sum
 }
 
-func Dupe(parts string, dupes int) []string {
-	out := []string{}
-	for i := 0; i < dupes; i++ {
-		out = append(out, parts)
+// ParseRecord reads a line like "???.### 1,1,3"
+func ParseRecord(line string) Record {
+	parts := strings.Split(line, " ")
+	return Record{
+		Pattern: parts[0],
+		Groups: lo.Map(strings.Split(parts[1], ","), func(item string, _ int) int {
+			return ez.Atoi(item)
+		}),
 	}
-	return out
 }
 
-func ProcessPattern(pattern string, set []int) int {
-	// Pre-build a cache to track
-	// The first slice represents the characters in the pattern
-	// The second slice represents whether or not the character can fulfill a given set
-	var cache [][]int
-	for i := 0; i < len(pattern); i++ {
-		// Append a extra set that'll never get matched to provide an exit from our recursion
-		cache = append(cache, make([]int, len(set)+1))
-		for j := 0; j < len(set)+1; j++ {
-			cache[i][j] = -1
-		}
+// Unfold repeats the pattern factor times joined by '?', and the groups factor times
+func (r Record) Unfold(factor int) Record {
+	patterns := make([]string, factor)
+	groups := make([]int, 0, len(r.Groups)*factor)
+	for i := 0; i < factor; i++ {
+		patterns[i] = r.Pattern
+		groups = append(groups, r.Groups...)
+	}
+	return Record{
+		Pattern: strings.Join(patterns, "?"),
+		Groups:  groups,
 	}
-
-	// Kick off the call to Iter, which will eventually return a result
-	return Iter(0, 0, pattern, set, cache)
 }
 
-func Iter(i, j int, pattern string, set []int, cache [][]int) int {
-	// Exit conditions for recursion
-	// We've made it to the end of the pattern
-	if i >= len(pattern) {
-		// If we've not fulfilled all of our sets, do not consider the iteration a success
-		if j < len(set) {
-			return 0
+// table builds ways[i][j], the number of arrangements of Pattern[i:] that fulfil Groups[j:]
+// It is filled from the end of the pattern backwards, so no recursion is needed however long the pattern is
+func (r Record) table() [][]int {
+	n := len(r.Pattern)
+	m := len(r.Groups)
+
+	// dots[i] is the count of '.' before i, so a group can be placed over i..k if no '.' falls within it
+	dots := make([]int, n+1)
+	for i := 0; i < n; i++ {
+		dots[i+1] = dots[i]
+		if r.Pattern[i] == '.' {
+			dots[i+1]++
 		}
-		return 1
 	}
 
-	// Check the cache for a given character/set having already been evaluated
-	// If so, return that count
-	if cache[i][j] != -1 {
-		return cache[i][j]
+	ways := make([][]int, n+2)
+	for i := range ways {
+		ways[i] = make([]int, m+1)
 	}
-
-	result := 0
-	if pattern[i] == '.' {
-		// If '.', move on to the next character, result doesn't change
-		result = Iter(i+1, j, pattern, set, cache)
-	} else {
-		// If '?', sum the result, as the ? is a wildcard and may fulfill a set
-		if pattern[i] == '?' {
-			result += Iter(i+1, j, pattern, set, cache)
-		}
-		// Recursion exit condition
-		if j < len(set) {
-			count := 0
-			// Starting at the character, examine the remainder of the pattern
-			for k := i; k < len(pattern); k++ {
-				// Stop counting when
-				// 1: we've counted higher than the set's size
-				// OR
-				// 2: we hit a '.'
-				// OR
-				// 3: (We've matched the set size AND the last character is a wildcard)
-				if count > set[j] || pattern[k] == '.' || count == set[j] && pattern[k] == '?' {
-					break
+	// Reaching the end with every group fulfilled is a success, one past the end lets a group ending on the
+	// last character skip its separator
+	ways[n][m] = 1
+	ways[n+1][m] = 1
+
+	for i := n - 1; i >= 0; i-- {
+		for j := m; j >= 0; j-- {
+			// If '.' or '?', the character can be operational, move on to the next character
+			if r.Pattern[i] != '#' {
+				ways[i][j] += ways[i+1][j]
+			}
+			// If '#' or '?', the character can start the next group, as long as the group fits
+			// without covering a '.', and isn't immediately followed by another '#'
+			if r.Pattern[i] != '.' && j < m {
+				end := i + r.Groups[j]
+				if end <= n && dots[end] == dots[i] && (end == n || r.Pattern[end] != '#') {
+					ways[i][j] += ways[end+1][j+1]
 				}
-				count += 1
 			}
+		}
+	}
+
+	return ways
+}
+
+// Count returns the number of possible arrangements of damaged and operational springs
+func (r Record) Count() int {
+	return r.table()[0][0]
+}
 
-			// If our count matches, it can fulfill the set
-			if count == set[j] {
-				// If we're not at the end of the pattern
-				// And the next character is a ?
-				if i+count < len(pattern) && pattern[i+count] == '?' {
-					// We can safely skip a character (treat it as a .) the set + an extra character and move to the next set
-					result += Iter(i+count+1, j+1, pattern, set, cache)
-				} else {
-					// Otherwise, continue on evaluating the next range of character
-					result += Iter(i+count, j+1, pattern, set, cache)
+// Arrangements lists up to limit of the valid arrangements, with every '?' resolved to '#' or '.'
+// The counting table is used to only walk into choices that lead to at least one arrangement
+func (r Record) Arrangements(limit int) []string {
+	ways := r.table()
+	n := len(r.Pattern)
+	m := len(r.Groups)
+
+	type state struct {
+		I   int
+		J   int
+		Out []byte
+	}
+	out := []string{}
+	stack := []state{{Out: []byte{}}}
+	for len(stack) > 0 && len(out) < limit {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if s.I >= n {
+			if s.J == m {
+				out = append(out, string(s.Out[:n]))
+			}
+			continue
+		}
+
+		// Pushed in reverse so placing a group is explored before leaving the spring operational
+		if r.Pattern[s.I] != '#' && ways[s.I+1][s.J] > 0 {
+			stack = append(stack, state{I: s.I + 1, J: s.J, Out: append(s.Out[:s.I:s.I], '.')})
+		}
+		if r.Pattern[s.I] != '.' && s.J < m {
+			end := s.I + r.Groups[s.J]
+			if end <= n && ways[s.I][s.J] > 0 && !strings.Contains(r.Pattern[s.I:end], ".") && (end == n || r.Pattern[end] != '#') && ways[end+1][s.J+1] > 0 {
+				next := append(s.Out[:s.I:s.I], strings.Repeat("#", r.Groups[s.J])...)
+				if end < n {
+					next = append(next, '.')
 				}
+				stack = append(stack, state{I: end + 1, J: s.J + 1, Out: next})
 			}
 		}
 	}
 
-	// Store the result
-	// Which is the number of sets a given character can fulfill
-	cache[i][j] = result
-
-	return result
+	return out
 }
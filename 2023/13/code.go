@@ -1,13 +1,15 @@
 package main
 
 import (
+	"aoc-in-go/ez"
+	"math/bits"
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -16,115 +18,113 @@ func main() {
 // 3. with: false (part1), and user input
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
+
+// Point is a row and column within a pattern
+type Point struct {
+	R int
+	C int
+}
+
+// Pattern stores each row and column as a bitmask where # is a set bit, patterns can be at most 64 wide or tall
+// Bit j of Rows[i] and bit i of Cols[j] are both the character at row i, column j
+type Pattern struct {
+	Rows []uint64
+	Cols []uint64
+}
+
+// Reflection is a line of reflection within a pattern
+// Axis is the number of rows above (or columns left of) the line, Smudges are the cells that differ from their mirror image,
+// given on the top (or left) side of the line
+type Reflection struct {
+	Axis     int
+	Vertical bool
+	Smudges  []Point
+}
+
 func run(part2 bool, input string) any {
 	grids := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n\n")
 
-	// Part 2
+	// Part 1 looks for a perfect reflection, part 2 for one with exactly one smudge
+	smudges := 0
 	if part2 {
-		sum := 0
-		for _, grid := range grids {
-			lineLen, lineCount := GridDim(grid)
-		gridLoop:
-			for i := 0; i < lineCount; i++ {
-				for j := 0; j < lineLen; j++ {
-					// smudgeGrid has the character at i,j coordinates flipped
-					smudgeGrid := Smudge(i, j, grid)
-
-					lines := strings.Split(strings.ReplaceAll(smudgeGrid, "\r\n", "\n"), "\n")
-					cols := func() []string {
-						out := make([]string, len(lines[0]))
-						for i := 0; i < len(lines[0]); i++ {
-							for j := 0; j < len(lines); j++ {
-								out[i] += string(lines[j][i])
-							}
-						}
-						return out
-					}()
-
-					top, bottom := Mirror(lines, i)
-					left, right := Mirror(cols, j)
-					// checkRange is the number of lines that were used to evaluate the mirror
-					checkRange := min(top, bottom)
-					checkRangeLR := min(left, right)
-					// If we have a mirrored set and the smudge is part of the mirrored set
-					if top != 0 && bottom != 0 && i+1 > top-checkRange && i+1 <= top+checkRange {
-						sum += 100 * top
-						break gridLoop
-					} else if left != 0 && right != 0 && j+1 > left-checkRangeLR && j+1 <= left+checkRangeLR {
-						sum += left
-						break gridLoop
-					}
-				}
-			}
-		}
-		return sum
+		smudges = 1
 	}
 
-	// Part 1
 	sum := 0
 	for _, grid := range grids {
-		lines := strings.Split(strings.ReplaceAll(grid, "\r\n", "\n"), "\n")
-		cols := func() []string {
-			out := make([]string, len(lines[0]))
-			for i := 0; i < len(lines[0]); i++ {
-				for j := 0; j < len(lines); j++ {
-					out[i] += string(lines[j][i])
-				}
-			}
-			return out
-		}()
-		top, bottom := Mirror(lines, 0)
-		if top == 0 || bottom == 0 {
-			left, _ := Mirror(cols, 0)
-			sum += left
-		} else {
-			sum += 100 * top
+		if r, ok := ParsePattern(grid).FindReflection(smudges); ok {
+			sum += r.Summary()
 		}
 	}
 	return sum
 }
 
-// Smudge flips the character at row i, in character j (0 indexed), on the grid and returns the smudged grid
-func Smudge(i, j int, grid string) string {
-	lines := strings.Split(strings.ReplaceAll(grid, "\r\n", "\n"), "\n")
-	currentChar := string(lines[i][j])
-	replace := "#"
-	if currentChar == "#" {
-		replace = "."
+// ParsePattern encodes a pattern's rows and columns as bitmasks
+func ParsePattern(grid string) Pattern {
+	lines := strings.Split(grid, "\n")
+	p := Pattern{
+		Rows: make([]uint64, len(lines)),
+		Cols: make([]uint64, len(lines[0])),
+	}
+	for i, line := range lines {
+		for j, char := range line {
+			if char == '#' {
+				p.Rows[i] |= 1 << j
+				p.Cols[j] |= 1 << i
+			}
+		}
+	}
+	return p
+}
+
+// FindReflection returns the first line of reflection where exactly k cells differ from their mirror image
+// Horizontal lines are checked before vertical, k = 0 is a perfect reflection
+func (p Pattern) FindReflection(k int) (Reflection, bool) {
+	if axis, ok := mirror(p.Rows, k); ok {
+		return Reflection{Axis: axis, Smudges: smudges(p.Rows, axis, false)}, true
+	}
+	if axis, ok := mirror(p.Cols, k); ok {
+		return Reflection{Axis: axis, Vertical: true, Smudges: smudges(p.Cols, axis, true)}, true
 	}
-	lines[i] = lines[i][:j] + replace + lines[i][j+1:]
-	return strings.Join(lines, "\n")
+	return Reflection{}, false
 }
 
-// GridDim just returns the dimenions of the grid as: Line Number, Character Count
-func GridDim(grid string) (int, int) {
-	lines := strings.Split(strings.ReplaceAll(grid, "\r\n", "\n"), "\n")
-	return len(lines[0]), len(lines)
+// Summary is the value the puzzle asks for, columns to the left or 100 times the rows above
+func (r Reflection) Summary() int {
+	if r.Vertical {
+		return r.Axis
+	}
+	return 100 * r.Axis
 }
 
-// Mirror checks the lines for mirrored set, returning the top and bottom count of rows
-// Provide smudge to skip lines that may cause the Mirror to return a match that doesn't contain the smuded row
-func Mirror(lines []string, smudge int) (int, int) {
-	for i := range lines {
-		// Smudge checker for part 2
-		// skip evaluating lines that are before the smudge is introduced
-		if i < smudge {
-			continue
+// mirror finds the first axis where the lines either side differ by exactly k bits in total
+func mirror(lines []uint64, k int) (int, bool) {
+	for axis := 1; axis < len(lines); axis++ {
+		diff := 0
+		for i := 0; axis-1-i >= 0 && axis+i < len(lines) && diff <= k; i++ {
+			diff += bits.OnesCount64(lines[axis-1-i] ^ lines[axis+i])
 		}
-		isMirror := true
-		for j := i + 1; j < len(lines); j++ {
-			// Prevent looking back beyond the first line
-			if i-(j-i)+1 < 0 {
-				break
-			}
-			if lines[j] != lines[i-(j-i)+1] {
-				isMirror = false
-			}
+		if diff == k {
+			return axis, true
 		}
-		if isMirror {
-			return i + 1, len(lines) - (i + 1)
+	}
+	return 0, false
+}
+
+// smudges lists the differing cells on the near side of the axis, transposed back to row/column when checking columns
+func smudges(lines []uint64, axis int, transposed bool) []Point {
+	out := []Point{}
+	for i := 0; axis-1-i >= 0 && axis+i < len(lines); i++ {
+		diff := lines[axis-1-i] ^ lines[axis+i]
+		for diff != 0 {
+			j := bits.TrailingZeros64(diff)
+			diff &= diff - 1
+			if transposed {
+				out = append(out, Point{R: j, C: axis - 1 - i})
+			} else {
+				out = append(out, Point{R: axis - 1 - i, C: j})
+			}
 		}
 	}
-	// We should never get here, but pass 0 in case we do...
-	return 0, len(lines)
+	return out
 }
@@ -2,14 +2,14 @@ package main
 
 import (
 	"aoc-in-go/ez"
+	"slices"
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
-	"github.com/samber/lo"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -18,145 +18,155 @@ func main() {
 // 3. with: false (part1), and user input
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
+
+type Direction int
+
+const (
+	North Direction = iota
+	West
+	South
+	East
+)
+
+// SpinCycle is the order a spin cycle tilts the platform in
+var SpinCycle = []Direction{North, West, South, East}
+
+// Platform holds the rocks as a flat row-major slice of 'O', '#' and '.'
+type Platform struct {
+	Rows  int
+	Cols  int
+	Cells []byte
+}
+
 func run(part2 bool, input string) any {
+	p := ParsePlatform(input)
+
+	// Part 2
+	if part2 {
+		return p.SpinLoad(1000000000)
+	}
+
+	// Part 1
+	p.Tilt(North)
+	return p.Load()
+}
+
+// ParsePlatform reads the puzzle input into a Platform
+func ParsePlatform(input string) *Platform {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
-	lineChars := make([][]string, len(lines))
-	for i, line := range lines {
-		lineChars[i] = strings.Split(line, "")
+	p := &Platform{
+		Rows:  len(lines),
+		Cols:  len(lines[0]),
+		Cells: make([]byte, 0, len(lines)*len(lines[0])),
 	}
-	sum := 0
+	for _, line := range lines {
+		p.Cells = append(p.Cells, line...)
+	}
+	return p
+}
 
-	if part2 {
-		// Part 2
-		loadFoundInARow := 0
-		loadCheck := map[int]int{}
-		loadPerCycle := []int{}
-		preVal := 0
-		for iter := 1; iter <= 1000000000; iter++ {
-			// Roll "up"
-			Roll(lineChars)
-			// West is now "up", North is "right"
-			lineChars = TransposeRight(lineChars)
-			// Roll "up"
-			Roll(lineChars)
-			// South is now "up", North is "down"
-			lineChars = TransposeRight(lineChars)
-			// Roll "up"
-			Roll(lineChars)
-			// East is now "up", North is "left"
-			lineChars = TransposeRight(lineChars)
-			// Roll "up"
-			Roll(lineChars)
-			// North is now "up"
-			lineChars = TransposeRight(lineChars)
-
-			loadVal := LoadNoMove(lineChars)
-			loadPerCycle = append(loadPerCycle, loadVal)
-			if loadCheck[loadVal] > 0 && loadCheck[preVal] == iter-1 {
-				loadFoundInARow++
-				if loadFoundInARow >= 1000 {
-					// Cycle found
-					endOfCycle := iter - 1000
-					startOfCycle := loadCheck[loadVal] - 1000
-					cycleLen := endOfCycle - startOfCycle
-					desiredCycle := (1000000000-startOfCycle)%cycleLen - 1
-					sum = loadPerCycle[startOfCycle+desiredCycle]
-					break
+// Tilt rolls every round rock as far as it will go in dir, in place
+// Each row or column is split into segments by the cube rocks, a segment just needs its round rocks counted
+// and then packed against the end it's rolling towards
+func (p *Platform) Tilt(dir Direction) {
+	// lines is how many rows or columns to process, length is how long each one is
+	// at converts a position along a line to the index in Cells, with position 0 being the end rocks roll towards
+	lines, length := p.Cols, p.Rows
+	var at func(line, pos int) int
+	switch dir {
+	case North:
+		at = func(line, pos int) int { return pos*p.Cols + line }
+	case South:
+		at = func(line, pos int) int { return (p.Rows-1-pos)*p.Cols + line }
+	case West:
+		lines, length = p.Rows, p.Cols
+		at = func(line, pos int) int { return line*p.Cols + pos }
+	case East:
+		lines, length = p.Rows, p.Cols
+		at = func(line, pos int) int { return line*p.Cols + p.Cols - 1 - pos }
+	}
+
+	for line := 0; line < lines; line++ {
+		start := 0
+		rocks := 0
+		for pos := 0; pos <= length; pos++ {
+			if pos < length && p.Cells[at(line, pos)] != '#' {
+				if p.Cells[at(line, pos)] == 'O' {
+					rocks++
 				}
-			} else {
-				loadFoundInARow = 0
+				continue
 			}
-			loadCheck[loadVal] = iter
-			preVal = loadVal
-
+			// End of a segment, pack its rocks at the start
+			for k := start; k < pos; k++ {
+				p.Cells[at(line, k)] = '.'
+				if k-start < rocks {
+					p.Cells[at(line, k)] = 'O'
+				}
+			}
+			start = pos + 1
+			rocks = 0
 		}
-	} else {
-		// Part 1
-		sum = Load(lineChars)
 	}
-
-	return sum
 }
 
-func Load(lines [][]string) int {
-	colStartLoad := lo.RepeatBy(len(lines[0]), func(index int) int {
-		return len(lines)
-	})
-	colLoad := make([]int, len(lines[0]))
-	for i, line := range lines {
-		for j, colChar := range line {
-			switch {
-			case colChar == "#":
-				colStartLoad[j] = len(lines) - i - 1
-			case colChar == "O":
-				colLoad[j] += colStartLoad[j]
-				colStartLoad[j]--
-			}
-		}
+// Spin runs a single spin cycle
+func (p *Platform) Spin() {
+	for _, dir := range SpinCycle {
+		p.Tilt(dir)
 	}
-	return ez.Sum(colLoad)
 }
 
-func LoadNoMove(lines [][]string) int {
+// Load is the total load on the north support beams, without moving any rocks
+func (p *Platform) Load() int {
 	sum := 0
-	for i, line := range lines {
-		for _, colChar := range line {
-			switch {
-			case colChar == "O":
-				sum += len(lines) - i
-			}
+	for i, c := range p.Cells {
+		if c == 'O' {
+			sum += p.Rows - i/p.Cols
 		}
 	}
 	return sum
 }
 
-func Roll(lines [][]string) {
-	colFallTo := lo.RepeatBy(len(lines[0]), func(index int) int {
-		return 0
-	})
-	for i, line := range lines {
-		for j, colChar := range line {
-			switch {
-			case colChar == "#":
-				colFallTo[j] = i + 1
-			case colChar == "O":
-				lines[colFallTo[j]][j] = "O"
-				if colFallTo[j] != i {
-					lines[i][j] = "."
-				}
-
-				colFallTo[j]++
-			}
+// Key is a compact encoding of where the round rocks are, one bit per cell, usable as a map key
+// The cube rocks never move so they don't need to be included
+func (p *Platform) Key() string {
+	bits := make([]byte, (len(p.Cells)+7)/8)
+	for i, c := range p.Cells {
+		if c == 'O' {
+			bits[i/8] |= 1 << (i % 8)
 		}
 	}
+	return string(bits)
 }
 
-func TransposeRight(slice [][]string) [][]string {
-	colCount := len(slice[0])
-	rowCount := len(slice)
-	result := make([][]string, colCount)
-	for i := range result {
-		result[i] = make([]string, rowCount)
-	}
-	for col := 0; col < colCount; col++ {
-		for row := 0; row < rowCount; row++ {
-			result[col][colCount-row-1] = slice[row][col]
+// Clone returns a copy of the platform that can be tilted independently
+func (p *Platform) Clone() *Platform {
+	return &Platform{Rows: p.Rows, Cols: p.Cols, Cells: slices.Clone(p.Cells)}
+}
+
+// SpinLoad returns the north load after the given number of spin cycles, leaving p untouched
+// The rocks settle into a loop, so once a state repeats the rest of the cycles can be skipped
+func (p *Platform) SpinLoad(cycles int) int {
+	p = p.Clone()
+	seen := map[string]int{}
+	loads := []int{}
+	for i := 0; i < cycles; i++ {
+		key := p.Key()
+		if first, ok := seen[key]; ok {
+			return loads[first+(cycles-first)%(i-first)]
 		}
+		seen[key] = i
+		loads = append(loads, p.Load())
+		p.Spin()
 	}
-	return result
+	return p.Load()
 }
 
-func TransposeLeft(slice [][]string) [][]string {
-	colCount := len(slice[0])
-	rowCount := len(slice)
-	result := make([][]string, colCount)
-	for i := range result {
-		result[i] = make([]string, rowCount)
-	}
-	for col := 0; col < colCount; col++ {
-		for row := 0; row < rowCount; row++ {
-			result[rowCount-row-1][col] = slice[row][col]
-		}
+// String draws the platform as it appears in the puzzle
+func (p *Platform) String() string {
+	lines := make([]string, p.Rows)
+	for i := range lines {
+		lines[i] = string(p.Cells[i*p.Cols : (i+1)*p.Cols])
 	}
-	return result
+	return strings.Join(lines, "\n")
 }
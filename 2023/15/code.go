@@ -2,14 +2,15 @@ package main
 
 import (
 	"aoc-in-go/ez"
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
-	"github.com/samber/lo"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -25,64 +26,11 @@ func run(part2 bool, input string) any {
 
 	// Part 2
 	if part2 {
-		type Lens struct {
-			Label string
-			Focal int
-		}
-		type Box struct {
-			Lenses []Lens
-		}
-		boxes := make([]Box, 256)
-
+		m := &LensHashMap{}
 		for _, line := range lines {
-			// Determine where - or = is in the string
-			opIdx := max(strings.Index(line, "-"), strings.Index(line, "="))
-			label := line[0:opIdx]
-			boxNo := Hash(label)
-			// Pointer so we can operate on the box lenses directly
-			box := &boxes[boxNo]
-			if box.Lenses == nil {
-				box.Lenses = []Lens{}
-			}
-			op := line[opIdx : opIdx+1]
-			switch op {
-			case "-":
-				// Remove matching label if found
-				box.Lenses = lo.Reject(box.Lenses, func(item Lens, _ int) bool {
-					return item.Label == label
-				})
-			case "=":
-				// Add or replace based on label
-				focal := ez.Atoi(line[opIdx+1:])
-				found := false
-				for i, lens := range box.Lenses {
-					if lens.Label == label {
-						found = true
-						box.Lenses[i].Focal = focal
-						break
-					}
-				}
-				if !found {
-					box.Lenses = append(box.Lenses, Lens{
-						Label: label,
-						Focal: focal,
-					})
-				}
-			}
-		}
-
-		for i, box := range boxes {
-			for j, lens := range box.Lenses {
-				// The focusing power of a single lens is the result of multiplying together:
-				// - One plus the box number of the lens in question.
-				// - The slot number of the lens within the box: 1 for the first lens, 2 for the second lens, and so on.
-				// - The focal length of the lens.
-				out := (1 + i) * (j + 1) * lens.Focal
-				sum += out
-			}
+			m.Apply(line)
 		}
-
-		return sum
+		return m.FocusingPower()
 	}
 
 	// Part 1
@@ -102,3 +50,87 @@ func Hash(line string) int {
 	}
 	return seq
 }
+
+type Lens struct {
+	Label string
+	Focal int
+}
+
+// LensHashMap is the HASHMAP procedure, 256 boxes of lenses keyed by the Hash of their label, each box keeps its lenses in order
+type LensHashMap struct {
+	Boxes [256][]Lens
+}
+
+// Set replaces the focal length of the lens with a matching label, keeping its slot, or adds it to the back of its box
+func (m *LensHashMap) Set(label string, focal int) {
+	box := &m.Boxes[Hash(label)]
+	for i, lens := range *box {
+		if lens.Label == label {
+			(*box)[i].Focal = focal
+			return
+		}
+	}
+	*box = append(*box, Lens{Label: label, Focal: focal})
+}
+
+// Remove takes the lens with a matching label out of its box if found, the lenses behind it move forward
+func (m *LensHashMap) Remove(label string) {
+	box := &m.Boxes[Hash(label)]
+	*box = slices.DeleteFunc(*box, func(lens Lens) bool {
+		return lens.Label == label
+	})
+}
+
+// Apply runs a single step of the initialization sequence, either "label=focal" or "label-"
+func (m *LensHashMap) Apply(step string) {
+	// Determine where - or = is in the string
+	opIdx := max(strings.Index(step, "-"), strings.Index(step, "="))
+	label := step[0:opIdx]
+	switch step[opIdx] {
+	case '-':
+		m.Remove(label)
+	case '=':
+		m.Set(label, ez.Atoi(step[opIdx+1:]))
+	}
+}
+
+// FocusingPower sums the focusing power of every lens in every box
+func (m *LensHashMap) FocusingPower() int {
+	sum := 0
+	for i, box := range m.Boxes {
+		for j, lens := range box {
+			// The focusing power of a single lens is the result of multiplying together:
+			// - One plus the box number of the lens in question.
+			// - The slot number of the lens within the box: 1 for the first lens, 2 for the second lens, and so on.
+			// - The focal length of the lens.
+			sum += (1 + i) * (j + 1) * lens.Focal
+		}
+	}
+	return sum
+}
+
+// String lists the non-empty boxes the way the puzzle does, eg. "Box 0: [rn 1] [cm 2]"
+func (m *LensHashMap) String() string {
+	out := []string{}
+	for i, box := range m.Boxes {
+		if len(box) == 0 {
+			continue
+		}
+		lenses := make([]string, len(box))
+		for j, lens := range box {
+			lenses[j] = fmt.Sprintf("[%s %d]", lens.Label, lens.Focal)
+		}
+		out = append(out, fmt.Sprintf("Box %d: %s", i, strings.Join(lenses, " ")))
+	}
+	return strings.Join(out, "\n")
+}
+
+// Trace applies each step and records the box contents after it, in the same format as the puzzle's walk-through
+func (m *LensHashMap) Trace(steps []string) []string {
+	out := make([]string, len(steps))
+	for i, step := range steps {
+		m.Apply(step)
+		out[i] = fmt.Sprintf("After %q:\n%s", step, m.String())
+	}
+	return out
+}
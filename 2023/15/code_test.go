@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const example = "rn=1,cm-,qp=3,cm=2,qp-,pc=4,ot=9,ab=5,pc-,pc=6,ot=7"
+
+func TestRunExample(t *testing.T) {
+	if got := run(false, example); got != 1320 {
+		t.Errorf("part 1 = %v, want 1320", got)
+	}
+	if got := run(true, example); got != 145 {
+		t.Errorf("part 2 = %v, want 145", got)
+	}
+}
+
+// The walk-through from the puzzle text, the box contents after each step of the example
+func TestTraceMatchesPuzzle(t *testing.T) {
+	want := []string{
+		"After \"rn=1\":\nBox 0: [rn 1]",
+		"After \"cm-\":\nBox 0: [rn 1]",
+		"After \"qp=3\":\nBox 0: [rn 1]\nBox 1: [qp 3]",
+		"After \"cm=2\":\nBox 0: [rn 1] [cm 2]\nBox 1: [qp 3]",
+		"After \"qp-\":\nBox 0: [rn 1] [cm 2]",
+		"After \"pc=4\":\nBox 0: [rn 1] [cm 2]\nBox 3: [pc 4]",
+		"After \"ot=9\":\nBox 0: [rn 1] [cm 2]\nBox 3: [pc 4] [ot 9]",
+		"After \"ab=5\":\nBox 0: [rn 1] [cm 2]\nBox 3: [pc 4] [ot 9] [ab 5]",
+		"After \"pc-\":\nBox 0: [rn 1] [cm 2]\nBox 3: [ot 9] [ab 5]",
+		"After \"pc=6\":\nBox 0: [rn 1] [cm 2]\nBox 3: [ot 9] [ab 5] [pc 6]",
+		"After \"ot=7\":\nBox 0: [rn 1] [cm 2]\nBox 3: [ot 7] [ab 5] [pc 6]",
+	}
+	got := (&LensHashMap{}).Trace(strings.Split(example, ","))
+	if len(got) != len(want) {
+		t.Fatalf("Trace returned %d steps, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("step %d:\n%s\nwant:\n%s", i+1, got[i], want[i])
+		}
+	}
+}
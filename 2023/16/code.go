@@ -1,15 +1,16 @@
 package main
 
 import (
+	"aoc-in-go/ez"
+	"runtime"
 	"strings"
 	"sync"
 
 	"github.com/jpillora/puzzler/harness/aoc"
-	"github.com/samber/lo"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -19,224 +20,216 @@ func main() {
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
 
-type Cell struct {
-	Char      string
-	Energized int
-	Tracked   []string
-	tMu       sync.RWMutex
+// Directions are bit flags so a single byte per tile can track every direction a beam has entered it travelling in
+const (
+	North uint8 = 1 << iota
+	East
+	South
+	West
+)
+
+// Beam is a position on the grid and the direction the beam is travelling in
+type Beam struct {
+	Row int
+	Col int
+	Dir uint8
 }
 
-func (c *Cell) GetTracked() []string {
-	c.tMu.RLock()
-	defer c.tMu.RUnlock()
-	return c.Tracked
+// Step moves the beam one tile in its current direction
+func (b Beam) Step() Beam {
+	switch b.Dir {
+	case North:
+		b.Row--
+	case East:
+		b.Col++
+	case South:
+		b.Row++
+	case West:
+		b.Col--
+	}
+	return b
 }
 
-func (c *Cell) AddTracked(s string) {
-	c.tMu.Lock()
-	defer c.tMu.Unlock()
-	c.Tracked = append(c.Tracked, s)
+// Turn returns the beam facing dir, moved one tile in that direction
+func (b Beam) Turn(dir uint8) Beam {
+	b.Dir = dir
+	return b.Step()
 }
 
-// var cells [][]Cell
-var rowCount int
-var colCount int
+// Grid is the contraption layout stored as a flat row-major slice of tiles
+type Grid struct {
+	Rows  int
+	Cols  int
+	Tiles []byte
+}
 
-func run(part2 bool, input string) any {
+// ParseGrid reads the puzzle input into a Grid
+func ParseGrid(input string) *Grid {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
-	rowCount = len(lines)
-	colCount = len(lines[0])
-
-	// Part 2
-	if part2 {
-		sumMax := 0
-		// Left and Rights
-		for i := 0; i <= rowCount; i++ {
-			cellCopyA := MakeCells(lines)
-			wgA := &sync.WaitGroup{}
-			wgA.Add(1)
-			go HandleBeam(wgA, cellCopyA, i, 0, "E")
-
-			cellCopyB := MakeCells(lines)
-			wgB := &sync.WaitGroup{}
-			wgB.Add(1)
-			go HandleBeam(wgB, cellCopyB, i, colCount-1, "W")
-
-			wgA.Wait()
-			wgB.Wait()
-
-			sumMax = max(sumMax, SumCells(cellCopyA), SumCells(cellCopyB))
-		}
-
-		// Tops and Bottoms
-		for i := 0; i <= colCount; i++ {
-			cellCopyA := MakeCells(lines)
-			wgA := &sync.WaitGroup{}
-			wgA.Add(1)
-			go HandleBeam(wgA, cellCopyA, 0, i, "S")
-
-			cellCopyB := MakeCells(lines)
-			wgB := &sync.WaitGroup{}
-			wgB.Add(1)
-			go HandleBeam(wgB, cellCopyB, rowCount-1, i, "N")
-
-			wgA.Wait()
-			wgB.Wait()
-
-			sumMax = max(sumMax, SumCells(cellCopyA), SumCells(cellCopyB))
-		}
-
-		return sumMax
+	grid := &Grid{
+		Rows:  len(lines),
+		Cols:  len(lines[0]),
+		Tiles: make([]byte, 0, len(lines)*len(lines[0])),
 	}
+	for _, line := range lines {
+		grid.Tiles = append(grid.Tiles, line...)
+	}
+	return grid
+}
 
-	// Part 1
-	cellCopy := MakeCells(lines)
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
-	go HandleBeam(wg, cellCopy, 0, 0, "E")
-	wg.Wait()
+// EdgeEntries returns every beam that can enter the grid from an edge, in a fixed order:
+// left edge heading E, right edge heading W, top edge heading S, then bottom edge heading N
+func (g *Grid) EdgeEntries() []Beam {
+	entries := make([]Beam, 0, 2*(g.Rows+g.Cols))
+	for i := 0; i < g.Rows; i++ {
+		entries = append(entries, Beam{Row: i, Col: 0, Dir: East})
+	}
+	for i := 0; i < g.Rows; i++ {
+		entries = append(entries, Beam{Row: i, Col: g.Cols - 1, Dir: West})
+	}
+	for i := 0; i < g.Cols; i++ {
+		entries = append(entries, Beam{Row: 0, Col: i, Dir: South})
+	}
+	for i := 0; i < g.Cols; i++ {
+		entries = append(entries, Beam{Row: g.Rows - 1, Col: i, Dir: North})
+	}
+	return entries
+}
 
-	return SumCells(cellCopy)
+// Tracer follows beams through a Grid, its buffers are reused between traces so repeated calls don't allocate
+// A Tracer is not safe for concurrent use, give each goroutine its own
+type Tracer struct {
+	grid  *Grid
+	seen  []uint8
+	stack []Beam
 }
 
-// Make cells generates a fresh, 0 initialized matrix of Cells
-func MakeCells(lines []string) [][]Cell {
-	cells := make([][]Cell, len(lines))
-	for i, line := range lines {
-		cells[i] = make([]Cell, len(line))
-
-		for j, char := range strings.Split(line, "") {
-			cells[i][j] = Cell{
-				Char:      char,
-				Energized: 0,
-				Tracked:   []string{},
-			}
-		}
+// NewTracer creates a Tracer for the given grid
+func NewTracer(grid *Grid) *Tracer {
+	return &Tracer{
+		grid:  grid,
+		seen:  make([]uint8, len(grid.Tiles)),
+		stack: make([]Beam, 0, 64),
 	}
-	return cells
 }
 
-// SumCells returns the count of Energized cells
-func SumCells(cells [][]Cell) int {
-	sum := 0
-	for i := range cells {
-		for j := range cells[i] {
-			if cells[i][j].Energized > 0 {
-				sum++
+// Energize traces a beam from start and returns the count of energized tiles
+func (t *Tracer) Energize(start Beam) int {
+	g := t.grid
+	clear(t.seen)
+	count := 0
+
+	t.stack = append(t.stack[:0], start)
+	for len(t.stack) > 0 {
+		beam := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+
+		// Follow the beam until it leaves the grid or repeats a tile and direction, pushing any split off beams to the stack
+		for beam.Row >= 0 && beam.Row < g.Rows && beam.Col >= 0 && beam.Col < g.Cols {
+			idx := beam.Row*g.Cols + beam.Col
+			if t.seen[idx]&beam.Dir != 0 {
+				break
+			}
+			if t.seen[idx] == 0 {
+				count++
+			}
+			t.seen[idx] |= beam.Dir
+
+			switch g.Tiles[idx] {
+			case '/':
+				// Change Directions SW, NE
+				switch beam.Dir {
+				case North:
+					beam = beam.Turn(East)
+				case East:
+					beam = beam.Turn(North)
+				case South:
+					beam = beam.Turn(West)
+				case West:
+					beam = beam.Turn(South)
+				}
+			case '\\':
+				// Change Directions SE, NW
+				switch beam.Dir {
+				case North:
+					beam = beam.Turn(West)
+				case East:
+					beam = beam.Turn(South)
+				case South:
+					beam = beam.Turn(East)
+				case West:
+					beam = beam.Turn(North)
+				}
+			case '|':
+				// Split (EW) or keep going (NS)
+				if beam.Dir == East || beam.Dir == West {
+					t.stack = append(t.stack, beam.Turn(South))
+					beam = beam.Turn(North)
+				} else {
+					beam = beam.Step()
+				}
+			case '-':
+				// Split (NS) or keep going (EW)
+				if beam.Dir == North || beam.Dir == South {
+					t.stack = append(t.stack, beam.Turn(East))
+					beam = beam.Turn(West)
+				} else {
+					beam = beam.Step()
+				}
+			default:
+				beam = beam.Step()
 			}
 		}
 	}
-	return sum
+
+	return count
 }
 
-// HandleBeam terminates, moves, redirects, or splits a beam given a point on the grid
-func HandleBeam(wg *sync.WaitGroup, cells [][]Cell, i, j int, dir string) {
-	// Exit if past the edge of the grid
-	if i < 0 || i >= rowCount || j < 0 || j >= colCount {
-		wg.Done()
-		return
+// BestEntry energizes the grid from every edge entry point using a pool of workers goroutines (GOMAXPROCS when workers < 1)
+// It returns the highest energized count and the entry beam that produced it, ties go to the first entry in EdgeEntries order
+func BestEntry(grid *Grid, workers int) (int, Beam) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
 	}
+	entries := grid.EdgeEntries()
+	counts := make([]int, len(entries))
 
-	// Exit if the path has entered the cell going in the same direction before
-	if lo.Contains(cells[i][j].GetTracked(), dir) {
-		wg.Done()
-		return
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracer := NewTracer(grid)
+			for i := range jobs {
+				counts[i] = tracer.Energize(entries[i])
+			}
+		}()
+	}
+	for i := range entries {
+		jobs <- i
 	}
+	close(jobs)
+	wg.Wait()
 
-	// Mark cell energized
-	cells[i][j].Energized++
-	// Track the direction the path is going for this cell to assist with exiting if the beam gets in a loop
-	cells[i][j].AddTracked(dir)
-
-	// Handle what to do
-	switch cells[i][j].Char {
-	case ".":
-		// Continue in current direction
-		switch dir {
-		case "N":
-			HandleBeam(wg, cells, i-1, j, "N")
-		case "E":
-			HandleBeam(wg, cells, i, j+1, "E")
-		case "S":
-			HandleBeam(wg, cells, i+1, j, "S")
-		case "W":
-			HandleBeam(wg, cells, i, j-1, "W")
-		}
-	case "/":
-		// Change Directions SW, NE
-		switch dir {
-		case "N":
-			HandleBeam(wg, cells, i, j+1, "E")
-		case "E":
-			HandleBeam(wg, cells, i-1, j, "N")
-		case "S":
-			HandleBeam(wg, cells, i, j-1, "W")
-		case "W":
-			HandleBeam(wg, cells, i+1, j, "S")
-		}
-	case "\\":
-		// Change Directions SE, NW
-		switch dir {
-		case "N":
-			HandleBeam(wg, cells, i, j-1, "W")
-		case "E":
-			HandleBeam(wg, cells, i+1, j, "S")
-		case "S":
-			HandleBeam(wg, cells, i, j+1, "E")
-		case "W":
-			HandleBeam(wg, cells, i-1, j, "N")
-		}
-	case "|":
-		// Split (EW) or keep going (NS)
-		switch dir {
-		case "N":
-			// Continue
-			HandleBeam(wg, cells, i-1, j, "N")
-		case "E":
-			// Split the current beam into two new beams
-			wg.Add(1)
-			go HandleBeam(wg, cells, i-1, j, "N")
-			wg.Add(1)
-			go HandleBeam(wg, cells, i+1, j, "S")
-
-			wg.Done()
-		case "S":
-			// Continue
-			HandleBeam(wg, cells, i+1, j, "S")
-		case "W":
-			// Split the current beam into two new beams
-			wg.Add(1)
-			go HandleBeam(wg, cells, i-1, j, "N")
-			wg.Add(1)
-			go HandleBeam(wg, cells, i+1, j, "S")
-
-			wg.Done()
-		}
-	case "-":
-		// Split (NS) or keep going (EW)
-		switch dir {
-		case "N":
-			// Split the current beam into two new beams
-			wg.Add(1)
-			go HandleBeam(wg, cells, i, j-1, "W")
-			wg.Add(1)
-			go HandleBeam(wg, cells, i, j+1, "E")
-
-			wg.Done()
-		case "E":
-			// Continue
-			HandleBeam(wg, cells, i, j+1, "E")
-		case "S":
-			// Split the current beam into two new beams
-			wg.Add(1)
-			go HandleBeam(wg, cells, i, j-1, "W")
-			wg.Add(1)
-			go HandleBeam(wg, cells, i, j+1, "E")
-
-			wg.Done()
-		case "W":
-			// Continue
-			HandleBeam(wg, cells, i, j-1, "W")
+	best := 0
+	for i := range counts {
+		if counts[i] > counts[best] {
+			best = i
 		}
 	}
+	return counts[best], entries[best]
+}
+
+func run(part2 bool, input string) any {
+	grid := ParseGrid(input)
+
+	// Part 2
+	if part2 {
+		count, _ := BestEntry(grid, 0)
+		return count
+	}
+
+	// Part 1
+	return NewTracer(grid).Energize(Beam{Row: 0, Col: 0, Dir: East})
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const example = `.|...\....
+|.-.\.....
+.....|-...
+........|.
+..........
+.........\
+..../.\\..
+.-.-/..|..
+.|....-|.\
+..//.|....`
+
+// fixture is a 110x110 grid like the real input, seeded so every run traces the same beams
+func fixture() *Grid {
+	r := rand.New(rand.NewSource(16))
+	rows := make([]string, 110)
+	for i := range rows {
+		row := []byte(strings.Repeat(".", 110))
+		for j := range row {
+			// Roughly 1 in 10 tiles is a mirror or splitter, about the same as the real input
+			if r.Intn(10) == 0 {
+				row[j] = `/\|-`[r.Intn(4)]
+			}
+		}
+		rows[i] = string(row)
+	}
+	return ParseGrid(strings.Join(rows, "\n"))
+}
+
+func TestRunExample(t *testing.T) {
+	if got := run(false, example); got != 46 {
+		t.Errorf("part 1 = %v, want 46", got)
+	}
+	if got := run(true, example); got != 51 {
+		t.Errorf("part 2 = %v, want 51", got)
+	}
+}
+
+// Spreading the entries across workers must not change the result, ties included
+func TestBestEntryWorkers(t *testing.T) {
+	grid := fixture()
+	wantCount, wantBeam := BestEntry(grid, 1)
+	for _, workers := range []int{0, 2, 8} {
+		count, beam := BestEntry(grid, workers)
+		if count != wantCount || beam != wantBeam {
+			t.Errorf("BestEntry(%d workers) = %d %v, want %d %v", workers, count, beam, wantCount, wantBeam)
+		}
+	}
+}
+
+func BenchmarkBestEntry(b *testing.B) {
+	grid := fixture()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BestEntry(grid, 0)
+	}
+}
+
+// BenchmarkBestEntrySequential is the single worker baseline for BenchmarkBestEntry
+func BenchmarkBestEntrySequential(b *testing.B) {
+	grid := fixture()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BestEntry(grid, 1)
+	}
+}
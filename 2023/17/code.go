@@ -9,7 +9,7 @@ import (
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -19,36 +19,81 @@ func main() {
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
 
+// Directions are ordered clockwise so turning is just an offset
+const (
+	North = iota
+	East
+	South
+	West
+)
+
+// Arrows are used when rendering a path, indexed by direction
+var Arrows = []string{"^", ">", "v", "<"}
+
+// Turn is a bit flag for which turns a crucible is allowed to make, going straight is always allowed
+type Turn int
+
+const (
+	TurnLeft Turn = 1 << iota
+	TurnRight
+	TurnReverse
+)
+
+// Rules describe how a crucible is allowed to move
+// MinRun is how many blocks it must move in a direction before it can turn or stop, MaxRun is the most it can move before it must turn
+type Rules struct {
+	MinRun int
+	MaxRun int
+	Turns  Turn
+}
+
+// Crucible is the standard crucible from part 1
+var Crucible = Rules{MinRun: 1, MaxRun: 3, Turns: TurnLeft | TurnRight}
+
+// UltraCrucible is the ultra crucible from part 2
+var UltraCrucible = Rules{MinRun: 4, MaxRun: 10, Turns: TurnLeft | TurnRight}
+
 // Cell contains information about the cell as a Path passes over it
 // It is primarily used as a cache key for optimization
 type Cell struct {
-	Direction string
-	Row       int
-	Col       int
-	DirCount  int
+	Row int
+	Col int
+	Dir int
+	Run int
 }
 
-// Path tracks the path's accumulated heat, and points (for debugging)
-// Path is composed of Cell
+// Path tracks the path's accumulated heat as it sits in the queue
 type Path struct {
 	Cell
-	Heat   int
-	Points []Point
+	Heat int
 }
 
-// Point is just a tracking Row Column collection for debugging
+// Point is just a tracking Row Column collection
 type Point struct {
 	R int
 	C int
 }
 
 func run(part2 bool, input string) any {
-	// Use a PriorityQueue to compare heat values, using a - b ensures we're only ever testing the path with the least heat
-	// ref: https://github.com/emirpasic/gods#priorityqueue
-	q := pq.NewWith(func(a, b interface{}) int {
-		return a.(Path).Heat - b.(Path).Heat
-	})
+	grid := ParseGrid(input)
+
+	// Part 1
+	rules := Crucible
+	if part2 {
+		// Part 2
+		rules = UltraCrucible
+	}
 
+	heat, _, ok := Solve(grid, rules)
+	if !ok {
+		ez.Log("Failed to solve the problem!")
+		return 0
+	}
+	return heat
+}
+
+// ParseGrid reads the puzzle input into a grid of heat loss values
+func ParseGrid(input string) [][]int {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
 	grid := make([][]int, len(lines))
 	for i, line := range lines {
@@ -57,130 +102,156 @@ func run(part2 bool, input string) any {
 			grid[i][j] = ez.Atoi(char)
 		}
 	}
+	return grid
+}
+
+// Solve finds the path with the least heat loss from the top left to the bottom right of the grid following the given rules
+// It returns the heat loss, every point along the path (starting at the top left), and false if no path exists
+func Solve(grid [][]int, rules Rules) (int, []Point, bool) {
+	// Use a PriorityQueue to compare heat values, using a - b ensures we're only ever testing the path with the least heat
+	// ref: https://github.com/emirpasic/gods#priorityqueue
+	q := pq.NewWith(func(a, b interface{}) int {
+		return a.(Path).Heat - b.(Path).Heat
+	})
 
 	targetRow := len(grid) - 1
 	targetCol := len(grid[0]) - 1
 	cache := make(map[Cell]int)
-	// Part 1
-	maxStraight := 3
-	minStraight := 0
-	if part2 {
-		// Part 2
-		maxStraight = 10
-		minStraight = 3
-	}
+	prev := make(map[Cell]Cell)
 
-	// Kick off going East and South on the grid
-	q.Enqueue(Path{
-		Cell: Cell{
-			Direction: "E",
-			Row:       0,
-			Col:       1,
-			DirCount:  1,
-		},
-		Heat:   0,
-		Points: []Point{{R: 0, C: 0}},
-	})
-	q.Enqueue(Path{
-		Cell: Cell{
-			Direction: "S",
-			Row:       1,
-			Col:       0,
-			DirCount:  1,
-		},
-		Heat:   0,
-		Points: []Point{{R: 0, C: 0}},
-	})
+	// Kick off in every direction from the start, anything leaving the grid is dropped
+	enqueue := func(from *Cell, row, col, dir, dirRun, heat int) {
+		if row < 0 || row > targetRow || col < 0 || col > targetCol {
+			return
+		}
+		next := Cell{Row: row, Col: col, Dir: dir, Run: dirRun}
+		heat += grid[row][col]
+		if cacheHeat, exists := cache[next]; exists && cacheHeat <= heat {
+			return
+		}
+		cache[next] = heat
+		if from != nil {
+			prev[next] = *from
+		}
+		q.Enqueue(Path{Cell: next, Heat: heat})
+	}
+	for dir := North; dir <= West; dir++ {
+		row, col := NextPoint(0, 0, dir)
+		enqueue(nil, row, col, dir, 1, 0)
+	}
 
 	for {
 		pathI, pathExists := q.Dequeue()
 		if !pathExists {
-			break
+			return 0, nil, false
 		}
 		path := pathI.(Path)
 
-		// Make sure path is within the grid
-		if path.Row < 0 || path.Row > targetRow || path.Col < 0 || path.Col > targetCol {
+		// Skip paths which have since been beaten to this cell
+		if cache[path.Cell] < path.Heat {
 			continue
 		}
 
-		// Calculate heat
-		heat := path.Heat + grid[path.Row][path.Col]
-
 		// Exit condition
-		if path.Row == targetRow && path.Col == targetCol {
-			if path.DirCount < minStraight {
-				continue
-			}
-
-			return heat
-		}
-
-		// Check cache
-		if cacheHeat, exists := cache[path.Cell]; exists {
-			if cacheHeat <= heat {
-				continue
-			}
+		if path.Row == targetRow && path.Col == targetCol && path.Run >= rules.MinRun {
+			return path.Heat, tracePath(prev, path.Cell), true
 		}
-		cache[path.Cell] = heat
 
 		// Turns
-		if path.DirCount > minStraight {
-			newDirs := []string{"E", "W"}
-			if path.Direction == "W" || path.Direction == "E" {
-				newDirs = []string{"N", "S"}
-			}
-
-			for _, newDir := range newDirs {
-				newDir := newDir
+		if path.Run >= rules.MinRun {
+			for _, turn := range []Turn{TurnLeft, TurnRight, TurnReverse} {
+				if rules.Turns&turn == 0 {
+					continue
+				}
+				newDir := TurnDir(path.Dir, turn)
 				nextRow, nextCol := NextPoint(path.Row, path.Col, newDir)
-				q.Enqueue(Path{
-					Cell: Cell{
-						Direction: newDir,
-						Row:       nextRow,
-						Col:       nextCol,
-						DirCount:  1,
-					},
-					Heat:   heat,
-					Points: append(path.Points, Point{R: path.Row, C: path.Col}),
-				})
+				enqueue(&path.Cell, nextRow, nextCol, newDir, 1, path.Heat)
 			}
 		}
 
 		// Go straight
-		if path.DirCount < maxStraight {
-			nextRow, nextCol := NextPoint(path.Row, path.Col, path.Direction)
-			q.Enqueue(Path{
-				Cell: Cell{
-					Direction: path.Direction,
-					Row:       nextRow,
-					Col:       nextCol,
-					DirCount:  path.DirCount + 1,
-				},
-				Heat:   heat,
-				Points: append(path.Points, Point{R: path.Row, C: path.Col}),
-			})
+		if path.Run < rules.MaxRun {
+			nextRow, nextCol := NextPoint(path.Row, path.Col, path.Dir)
+			enqueue(&path.Cell, nextRow, nextCol, path.Dir, path.Run+1, path.Heat)
+		}
+	}
+}
+
+// tracePath walks the prev links back to the start, returning the points in travel order
+func tracePath(prev map[Cell]Cell, end Cell) []Point {
+	points := []Point{{R: end.Row, C: end.Col}}
+	cell := end
+	for {
+		p, exists := prev[cell]
+		if !exists {
+			break
 		}
+		points = append(points, Point{R: p.Row, C: p.Col})
+		cell = p
 	}
+	points = append(points, Point{R: 0, C: 0})
 
-	ez.Log("Failed to solve the problem!")
-	return 0
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+	return points
+}
+
+// TurnDir returns the direction after making the given turn
+func TurnDir(dir int, turn Turn) int {
+	switch turn {
+	case TurnLeft:
+		return (dir + 3) % 4
+	case TurnRight:
+		return (dir + 1) % 4
+	case TurnReverse:
+		return (dir + 2) % 4
+	}
+	return dir
 }
 
-// NextPoint returns the row and column value given a direction (NSEW) to go in
-func NextPoint(row, col int, newDir string) (int, int) {
-	rowMod := 0
-	colMod := 0
-	switch newDir {
-	case "N":
-		rowMod = -1
-	case "S":
-		rowMod = 1
-	case "E":
-		colMod = 1
-	case "W":
-		colMod = -1
+// NextPoint returns the row and column value given a direction to go in
+func NextPoint(row, col, dir int) (int, int) {
+	switch dir {
+	case North:
+		row--
+	case South:
+		row++
+	case East:
+		col++
+	case West:
+		col--
 	}
 
-	return row + rowMod, col + colMod
+	return row, col
+}
+
+// Render draws the grid with the path overlaid as arrows showing the direction of travel into each cell
+func Render(grid [][]int, path []Point) string {
+	out := make([][]string, len(grid))
+	for i := range grid {
+		out[i] = make([]string, len(grid[i]))
+		for j := range grid[i] {
+			out[i][j] = string(rune('0' + grid[i][j]))
+		}
+	}
+	for i := 1; i < len(path); i++ {
+		from, to := path[i-1], path[i]
+		dir := North
+		switch {
+		case to.R > from.R:
+			dir = South
+		case to.C > from.C:
+			dir = East
+		case to.C < from.C:
+			dir = West
+		}
+		out[to.R][to.C] = Arrows[dir]
+	}
+
+	lines := make([]string, len(out))
+	for i := range out {
+		lines[i] = strings.Join(out[i], "")
+	}
+	return strings.Join(lines, "\n")
 }
@@ -3,17 +3,19 @@ package main
 import (
 	"aoc-in-go/ez"
 	"fmt"
-	"math"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
-	"github.com/samber/lo"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -23,95 +25,177 @@ func main() {
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
 
-type Dig struct {
-	ez.Point
-	Hex string
+// Encoding is how a line of the dig plan is read into an Instruction
+type Encoding int
+
+const (
+	// Plain uses the direction and distance as written, eg. "R 6 (#70c710)"
+	Plain Encoding = iota
+	// Hex decodes the color, the first 5 hex digits are the distance and the last is the direction (0=R 1=D 2=L 3=U)
+	Hex
+)
+
+// Instruction is a single step of the dig plan, Color is only set with the Plain encoding
+type Instruction struct {
+	Dir   byte
+	Dist  int
+	Color string
+}
+
+// Point is a position in the lagoon, Y increases going down
+type Point struct {
+	X int
+	Y int
 }
 
 var re = regexp.MustCompile(`(\w+) (\d+) \(#(\w+)\)`)
 
 func run(part2 bool, input string) any {
+	encoding := Plain
+	if part2 {
+		encoding = Hex
+	}
+	plan, err := ParsePlan(input, encoding)
+	if err != nil {
+		return err
+	}
+
+	return Area(plan)
+}
+
+// ParsePlan reads every line of the dig plan using the given encoding
+func ParsePlan(input string, encoding Encoding) ([]Instruction, error) {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
-	var digs []Dig
-
-	// Start on an imaginary graph at the center: 0,0
-	startPoint := ez.Point{X: 0.0, Y: 0.0}
-	digs = append(digs, Dig{
-		Point: startPoint,
-		Hex:   "",
-	})
-
-	prevPoint := startPoint
-	for i := range lines {
-		var dist int
-		var dir string
-		var hex string
-
-		parts := re.FindStringSubmatch(lines[i])
-		hex = parts[3]
-
-		if !part2 {
-			// Part 1
-			dist = ez.Atoi(parts[2])
-			dir = parts[1]
-		} else {
-			// Part 2
-			distParsed, _ := strconv.ParseInt(hex[0:5], 16, 0)
-			dist = int(distParsed)
-
-			switch hex[5:] {
-			case "0":
-				dir = "R"
-			case "1":
-				dir = "D"
-			case "2":
-				dir = "L"
-			case "3":
-				dir = "U"
+	plan := make([]Instruction, 0, len(lines))
+	for i, line := range lines {
+		parts := re.FindStringSubmatch(line)
+		if parts == nil {
+			return nil, fmt.Errorf("line %d: unrecognised instruction %q", i+1, line)
+		}
+
+		if encoding == Plain {
+			if len(parts[1]) != 1 || !strings.Contains("RDLU", parts[1]) {
+				return nil, fmt.Errorf("line %d: invalid direction %q", i+1, parts[1])
 			}
+			plan = append(plan, Instruction{Dir: parts[1][0], Dist: ez.Atoi(parts[2]), Color: "#" + parts[3]})
+			continue
 		}
 
-		// Add individual dig increments
-		// This is not performant and brute-force, but necessary for correctness as the trench may overlap itself
-		for j := 1; j <= dist; j++ {
-			nextPoint := NextPoint(dir, 1, prevPoint)
-			digs = append(digs, Dig{
-				Point: nextPoint,
-				Hex:   hex,
-			})
-			prevPoint = nextPoint
+		// 5 hex digits of distance then the direction, checked before slicing so a short color can't panic
+		hex := parts[3]
+		if len(hex) != 6 || hex[5] < '0' || hex[5] > '3' {
+			return nil, fmt.Errorf("line %d: invalid color instruction #%s", i+1, hex)
+		}
+		dist, err := strconv.ParseInt(hex[0:5], 16, 0)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid color instruction #%s", i+1, hex)
 		}
+		plan = append(plan, Instruction{Dir: "RDLU"[hex[5]-'0'], Dist: int(dist)})
 	}
+	return plan, nil
+}
 
-	// Get unique trench points, this is the perimeter
-	// This felt necessary to account for when the trench digging overlaps with a previously dug trench
-	uniqTrenches := lo.UniqBy(digs, func(item Dig) string {
-		return fmt.Sprintf("%v,%v", item.Point.X, item.Point.Y)
-	})
-	// Calculate the inner area using shoelace
-	locs := lo.Map(digs, func(item Dig, _ int) ez.Point {
-		return item.Point
-	})
-	inner := ez.Picks(math.Abs(ez.Shoelace(locs)), len(locs))
-
-	// combine trench points with inner shoelace area
-	return int64(float64(len(uniqTrenches)) + inner)
+// Vertices returns the corner of every instruction, starting and ending at 0,0
+func Vertices(plan []Instruction) []Point {
+	points := make([]Point, 0, len(plan)+1)
+	p := Point{}
+	points = append(points, p)
+	for _, ins := range plan {
+		p = NextPoint(ins.Dir, ins.Dist, p)
+		points = append(points, p)
+	}
+	return points
 }
 
-func NextPoint(dir string, dist float64, prevPoint ez.Point) ez.Point {
-	xMod := .0
-	yMod := .0
+// Area is the number of cubic meters the lagoon holds, the trench itself plus everything inside it
+// Shoelace gives twice the area enclosed by the trench's center line, and Pick's theorem turns that into the inner points.
+// Adding the trench back on top, inner + trench = (2A + trench)/2 + 1, which stays exact in integers
+func Area(plan []Instruction) int {
+	points := Vertices(plan)
+	twiceArea := 0
+	trench := 0
+	for i := 1; i < len(points); i++ {
+		twiceArea += points[i-1].X*points[i].Y - points[i].X*points[i-1].Y
+		trench += plan[i-1].Dist
+	}
+	if twiceArea < 0 {
+		twiceArea = -twiceArea
+	}
+	return (twiceArea+trench)/2 + 1
+}
 
+func NextPoint(dir byte, dist int, prevPoint Point) Point {
 	switch dir {
-	case "U":
-		yMod += dist
-	case "D":
-		yMod -= dist
-	case "L":
-		xMod -= dist
-	case "R":
-		xMod += dist
+	case 'U':
+		prevPoint.Y -= dist
+	case 'D':
+		prevPoint.Y += dist
+	case 'L':
+		prevPoint.X -= dist
+	case 'R':
+		prevPoint.X += dist
+	}
+	return prevPoint
+}
+
+// bounds returns the top left of the plan and how much it must be divided by to fit within maxSize in both directions
+func bounds(points []Point, maxSize int) (Point, int, int, int) {
+	minP, maxP := points[0], points[0]
+	for _, p := range points {
+		minP = Point{X: min(minP.X, p.X), Y: min(minP.Y, p.Y)}
+		maxP = Point{X: max(maxP.X, p.X), Y: max(maxP.Y, p.Y)}
+	}
+	span := max(maxP.X-minP.X, maxP.Y-minP.Y) + 1
+	scale := max((span+maxSize-1)/maxSize, 1)
+	return minP, scale, (maxP.X-minP.X)/scale + 1, (maxP.Y-minP.Y)/scale + 1
+}
+
+// RenderSVG draws the trench outline as an SVG no larger than maxSize pixels, downsampling huge plans to fit
+// Each segment is stroked in its instruction's color, or black when it has none
+func RenderSVG(plan []Instruction, maxSize int) string {
+	points := Vertices(plan)
+	origin, scale, width, height := bounds(points, maxSize)
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	for i := 1; i < len(points); i++ {
+		stroke := plan[i-1].Color
+		if stroke == "" {
+			stroke = "#000000"
+		}
+		fmt.Fprintf(sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="1"/>`+"\n",
+			(points[i-1].X-origin.X)/scale, (points[i-1].Y-origin.Y)/scale,
+			(points[i].X-origin.X)/scale, (points[i].Y-origin.Y)/scale, stroke)
 	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// RenderPNG draws the trench outline as a PNG no larger than maxSize pixels, downsampling huge plans to fit
+// Each segment is drawn in its instruction's color, or black when it has none
+func RenderPNG(w io.Writer, plan []Instruction, maxSize int) error {
+	points := Vertices(plan)
+	origin, scale, width, height := bounds(points, maxSize)
 
-	return ez.Point{X: prevPoint.X + xMod, Y: prevPoint.Y + yMod}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for i := 1; i < len(points); i++ {
+		c := color.RGBA{A: 0xff}
+		if plan[i-1].Color != "" {
+			if v, err := strconv.ParseUint(plan[i-1].Color[1:], 16, 32); err == nil {
+				c = color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+			}
+		}
+		// Segments are always straight up, down, left, or right, so just walk the scaled line
+		from := Point{X: (points[i-1].X - origin.X) / scale, Y: (points[i-1].Y - origin.Y) / scale}
+		to := Point{X: (points[i].X - origin.X) / scale, Y: (points[i].Y - origin.Y) / scale}
+		for x := min(from.X, to.X); x <= max(from.X, to.X); x++ {
+			for y := min(from.Y, to.Y); y <= max(from.Y, to.Y); y++ {
+				img.Set(x, y, c)
+			}
+		}
+	}
+	return png.Encode(w, img)
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+const example = `R 6 (#70c710)
+D 5 (#0dc571)
+L 2 (#5713f0)
+D 2 (#d2c081)
+R 2 (#59c680)
+D 2 (#411b91)
+L 5 (#8ceee2)
+U 2 (#caa173)
+L 1 (#1b58a2)
+U 2 (#caa171)
+R 2 (#7807d2)
+U 3 (#a77fa3)
+L 2 (#015232)
+U 2 (#7a21e3)`
+
+func TestRunExample(t *testing.T) {
+	if got := run(false, example); got != 62 {
+		t.Errorf("part 1 = %v, want 62", got)
+	}
+	if got := run(true, example); got != 952408144115 {
+		t.Errorf("part 2 = %v, want 952408144115", got)
+	}
+}
+
+// Malformed colors are an error, never a panic
+func TestParsePlanInvalidColor(t *testing.T) {
+	for _, line := range []string{"R 6 (#70c)", "R 6 (#70c7104)", "R 6 (#70c714)", "R 6 (#zzzzz0)"} {
+		if _, err := ParsePlan(line, Hex); err == nil {
+			t.Errorf("ParsePlan(%q, Hex) = nil error, want invalid color", line)
+		}
+	}
+}
+
+// Only R, D, L and U are directions, anything else would silently be skipped by NextPoint
+func TestParsePlanInvalidDirection(t *testing.T) {
+	for _, line := range []string{"X 5 (#70c710)", "RD 5 (#70c710)", "r 5 (#70c710)"} {
+		if _, err := ParsePlan(line, Plain); err == nil {
+			t.Errorf("ParsePlan(%q, Plain) = nil error, want invalid direction", line)
+		}
+	}
+	if _, err := ParsePlan("R 6 (#70c710)\nX 5 (#0dc571)\nL 6 (#5713f0)\nU 5 (#d2c081)", Plain); err == nil {
+		t.Errorf("ParsePlan with an X step = nil error, want invalid direction")
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"aoc-in-go/ez"
 	"container/list"
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -11,7 +12,7 @@ import (
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -55,7 +56,8 @@ func run(part2 bool, input string) any {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
 	// Build the modList
 	modList := make(ModList)
-	for _, line := range lines {
+	for i, line := range lines {
+		ez.SetLine(i+1, line)
 		parts := strings.Split(line, " -> ")
 		outputs := strings.Split(parts[1], ", ")
 		if parts[0] == "broadcaster" {
@@ -97,6 +99,9 @@ func run(part2 bool, input string) any {
 			return 1
 		}
 
+		if err := ez.CheckAssumptions(modList.Assumptions()...); err != nil {
+			return err
+		}
 		return modList.PushButton2()
 	}
 
@@ -238,7 +243,7 @@ func (l ModList) PushButton2() int {
 	parents := map[string]int{}
 	rxParent := ""
 	for label, module := range l {
-		if module.Output[0] == "rx" {
+		if lo.Contains(module.Output, "rx") {
 			rxParent = label
 		}
 	}
@@ -286,3 +291,47 @@ func (l ModList) PushButton2() int {
 	vals := lo.Values(parents)
 	return ez.LCM(vals[0], vals[1], vals[2:]...)
 }
+
+// Assumptions are the properties of the input PushButton2 relies on, checked before solving
+// rx must be fed by a single conjunction, whose own inputs are conjunctions that each go low on a fixed cycle
+func (l ModList) Assumptions() []ez.Assumption {
+	feeders := func(label string) []string {
+		out := []string{}
+		for from, module := range l {
+			if lo.Contains(module.Output, label) {
+				out = append(out, from)
+			}
+		}
+		return out
+	}
+
+	return []ez.Assumption{
+		ez.Assume("there is a broadcaster", func() error {
+			_, ok := l["broadcaster"]
+			return ez.Expectf(ok, "no broadcaster module")
+		}),
+		ez.Assume("a single conjunction feeds rx", func() error {
+			in := feeders("rx")
+			if len(in) != 1 {
+				return fmt.Errorf("rx is fed by %d modules %v", len(in), in)
+			}
+			return ez.Expectf(l[in[0]].Type == "&", "rx is fed by %s%s", l[in[0]].Type, in[0])
+		}),
+		ez.Assume("the module feeding rx is fed by two or more conjunctions", func() error {
+			in := feeders("rx")
+			if len(in) != 1 {
+				return fmt.Errorf("rx has no single feeder")
+			}
+			parents := feeders(in[0])
+			if len(parents) < 2 {
+				return fmt.Errorf("%s is fed by %d modules", in[0], len(parents))
+			}
+			for _, p := range parents {
+				if l[p].Type != "&" {
+					return fmt.Errorf("%s is fed by %s%s", in[0], l[p].Type, p)
+				}
+			}
+			return nil
+		}),
+	}
+}
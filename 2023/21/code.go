@@ -6,11 +6,10 @@ import (
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
-	"github.com/samber/lo"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -20,194 +19,358 @@ func main() {
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
 
-type Cell struct {
-	R    int
-	C    int
-	Type string
+// MaxRadius is how many tiles out from the start the distance field may grow while waiting for it to become periodic
+const MaxRadius = 12
+
+type Point struct {
+	R int
+	C int
 }
 
-type Step struct {
-	Cell Cell
+// Grid is the garden map, Rocks is flat and row-major
+type Grid struct {
+	Rows  int
+	Cols  int
+	Rocks []bool
+	Start Point
 }
 
-type Grid [][]Cell
+// Field holds BFS distances from the start across every tile within Radius tiles of the start tile, -1 is unreachable
+type Field struct {
+	Grid   *Grid
+	Radius int
+	Dist   []int
+}
 
-type Steps []Step
+// Report explains which properties of the input held while solving the infinite garden
+// Only Stable is needed by ReachableInfinite, the rest are what the usual quadratic shortcut relies on
+type Report struct {
+	Square        bool
+	StartCentered bool
+	ClearStartRow bool
+	ClearStartCol bool
+	ClearEdges    bool
+	// Radius is how many tiles out from the start the distances became periodic
+	Radius int
+	Stable bool
+}
 
 func run(part2 bool, input string) any {
-	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
-
-	// Map the grid to Cells
-	grid := make(Grid, len(lines))
-	var startCell Cell
-	for i, line := range lines {
-		grid[i] = make([]Cell, len(line))
-		for j, char := range strings.Split(line, "") {
-			grid[i][j] = Cell{
-				R: i,
-				C: j,
-				Type: func() string {
-					if char == "#" {
-						return char
-					}
-					return "."
-				}(),
-			}
-
-			if char == "S" {
-				startCell = grid[i][j]
-			}
-		}
+	if err := ez.CheckAssumptions(Assumptions(input)...); err != nil {
+		return err
 	}
+	grid := ParseGrid(input)
 
 	// Part 2
 	if part2 {
-		if len(lines) < 20 {
-			// Example, skip
-			return 1
+		stepsToTake := 26501365
+		if grid.Rows < 20 {
+			// Example, the largest step count given in the puzzle
+			stepsToTake = 5000
 		}
-
-		// I'll be honest, the math here is a bit out of my league and I kinda get it but not to the point I know what's going
-		// I converted other solutions that were posted to Go and it worked
-		// Ultimately, we're using 3 points along a quadratic curve of equal distance to determine the quadratic coefficients
-
-		// Grid is square with all edges and the starting column/row are not blocked by a #
-		sqLen := len(lines)
-		allStepsToTake := 26501365
-		// Our "x", for the quadratic solution at the end
-		x := allStepsToTake / sqLen
-		// Capture points for our a, b, and c "step counts"/points
-		cCapture := allStepsToTake % sqLen
-		bCapture := cCapture + sqLen
-		aCapture := cCapture + 2*sqLen
-
-		// We'll expand the grid to support at least 2*sqLen + cCapture
-		grid = ExpandGrid(grid, 4)
-		// Adjust our startCell so we don't hit the edges
-		startCell.C = startCell.C + (2 * sqLen)
-		startCell.R = startCell.R + (2 * sqLen)
-
-		steps := make(Steps, 0)
-		steps = append(steps, Step{Cell: startCell})
-		var aStepCount, bStepCount, cStepCount int
-		for i := 0; i < aCapture; i++ {
-			steps = steps.TakeSteps(grid)
-			// Once we hit one of the capture points, store those values
-			if i+1 == aCapture {
-				aStepCount = len(steps)
-			}
-			if i+1 == bCapture {
-				bStepCount = len(steps)
-			}
-			if i+1 == cCapture {
-				cStepCount = len(steps)
-			}
+		count, report, err := grid.ReachableInfinite(stepsToTake)
+		// Show which properties of the input held, including when it couldn't be solved
+		ez.Logn(report.String())
+		if err != nil {
+			return err
 		}
-
-		// Math the coefficients, I'm a web developer, not a mathematician, not sure how these actually work
-		c := cStepCount
-		a := (aStepCount + cStepCount - 2*bStepCount) / 2
-		b := bStepCount - cStepCount - a
-
-		// The quadratic equation to produce our result
-		return a*x*x + b*x + c
+		return count
 	}
 
 	// Part 1
 	stepsToTake := 64
-	if len(lines) < 20 {
+	if grid.Rows < 20 {
 		// Example
 		stepsToTake = 6
 	}
+	return grid.Reachable(stepsToTake)
+}
 
-	steps := make(Steps, 0)
-	steps = append(steps, Step{Cell: startCell})
-	for i := 0; i < stepsToTake; i++ {
-		steps = steps.TakeSteps(grid)
+// ParseGrid maps the input to a Grid, S is recorded as the start and treated as a garden plot
+func ParseGrid(input string) *Grid {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	g := &Grid{
+		Rows:  len(lines),
+		Cols:  len(lines[0]),
+		Rocks: make([]bool, len(lines)*len(lines[0])),
 	}
+	for i, line := range lines {
+		for j, char := range line {
+			g.Rocks[i*g.Cols+j] = char == '#'
+			if char == 'S' {
+				g.Start = Point{R: i, C: j}
+			}
+		}
+	}
+	return g
+}
 
-	return len(steps)
+// Reachable counts the plots in the original garden that can be stood on after exactly steps steps
+// Steps can be wasted by going back and forth, so any plot at a distance <= steps with the same parity is reachable
+func (g *Grid) Reachable(steps int) int {
+	f := g.Distances(0)
+	count := 0
+	for _, d := range f.Dist {
+		if reachable(d, steps) {
+			count++
+		}
+	}
+	return count
 }
 
-// ExpandGrid takes a Grid and replicates it given a factor count, inclusive of the original grid
-func ExpandGrid(grid Grid, factor int) Grid {
-	initLen := len(grid)
-	// Expand the columns
-	for i := 0; i < initLen; i++ {
-		line := grid[i]
-		for j := 0; j < factor; j++ {
-			grid[i] = append(grid[i], line...)
+// Distances runs a single BFS from the start over the tiled garden, covering radius tiles in every direction
+func (g *Grid) Distances(radius int) *Field {
+	f := &Field{
+		Grid:   g,
+		Radius: radius,
+		Dist:   make([]int, (2*radius+1)*(2*radius+1)*len(g.Rocks)),
+	}
+	for i := range f.Dist {
+		f.Dist[i] = -1
+	}
+	rows := (2*radius + 1) * g.Rows
+	cols := (2*radius + 1) * g.Cols
+
+	start := Point{R: radius*g.Rows + g.Start.R, C: radius*g.Cols + g.Start.C}
+	f.Dist[start.R*cols+start.C] = 0
+	q := []Point{start}
+	for len(q) > 0 {
+		p := q[0]
+		q = q[1:]
+		d := f.Dist[p.R*cols+p.C]
+		for _, n := range []Point{{R: p.R - 1, C: p.C}, {R: p.R + 1, C: p.C}, {R: p.R, C: p.C - 1}, {R: p.R, C: p.C + 1}} {
+			if n.R < 0 || n.R >= rows || n.C < 0 || n.C >= cols {
+				continue
+			}
+			if g.Rocks[(n.R%g.Rows)*g.Cols+n.C%g.Cols] || f.Dist[n.R*cols+n.C] != -1 {
+				continue
+			}
+			f.Dist[n.R*cols+n.C] = d + 1
+			q = append(q, n)
 		}
 	}
-	// Expand the rows
-	for j := 0; j < factor; j++ {
-		for i := 0; i < initLen; i++ {
-			grid = append(grid, grid[i])
+
+	return f
+}
+
+// At returns the distance to cell r,c of the tile tr,tc, where tile 0,0 holds the start
+func (f *Field) At(tr, tc, r, c int) int {
+	cols := (2*f.Radius + 1) * f.Grid.Cols
+	return f.Dist[((tr+f.Radius)*f.Grid.Rows+r)*cols+(tc+f.Radius)*f.Grid.Cols+c]
+}
+
+// periodic checks every tile on the ring at radius is exactly one tile's width (or height) further than its inner neighbour
+// Once that holds, every tile further out can be extrapolated from the ring without walking it
+func (f *Field) periodic(radius int) bool {
+	g := f.Grid
+	same := func(outer, inner, shift int) bool {
+		if outer == -1 || inner == -1 {
+			return outer == inner
 		}
+		return outer-inner == shift
 	}
-	// Return a new Grid with all new cells
-	rowLen := len(grid)
-	colLen := len(grid[0])
-	newGrid := make(Grid, rowLen)
-	for i := 0; i < len(grid); i++ {
-		newGrid[i] = make([]Cell, colLen)
-		for j := 0; j < len(grid[i]); j++ {
-			newGrid[i][j] = Cell{
-				R:    i,
-				C:    j,
-				Type: grid[i][j].Type,
+	for o := -radius; o <= radius; o++ {
+		for r := 0; r < g.Rows; r++ {
+			for c := 0; c < g.Cols; c++ {
+				if !same(f.At(o, radius, r, c), f.At(o, radius-1, r, c), g.Cols) ||
+					!same(f.At(o, -radius, r, c), f.At(o, -radius+1, r, c), g.Cols) ||
+					!same(f.At(radius, o, r, c), f.At(radius-1, o, r, c), g.Rows) ||
+					!same(f.At(-radius, o, r, c), f.At(-radius+1, o, r, c), g.Rows) {
+					return false
+				}
 			}
 		}
 	}
-
-	return newGrid
+	return true
 }
 
-// TakeSteps iterates over the current steps and produces a new set of "steps", evaluating valid moves N/E/S/W
-func (s Steps) TakeSteps(grid Grid) []Step {
-	newSteps := make([]Step, 0)
+// ReachableInfinite counts the plots that can be stood on after exactly steps steps when the garden repeats forever
+// A distance field is grown until the outer ring of tiles is periodic, tiles within that ring are counted directly,
+// and every tile beyond is counted from the ring's distances in closed form, so any step count costs the same
+func (g *Grid) ReachableInfinite(steps int) (int, Report, error) {
+	report := g.Check()
 
-	for _, step := range s {
-		i := step.Cell.R
-		j := step.Cell.C
-		if grid[i][j].C != j || grid[i][j].R != i {
-			ez.Log("bad grid", i, j, grid[i][j].R, grid[i][j].C)
+	var f *Field
+	for radius := 2; radius <= MaxRadius; radius++ {
+		// One extra tile of padding so the ring isn't skewed by paths cut off at the edge of the field
+		f = g.Distances(radius + 1)
+		if f.periodic(radius) {
+			report.Radius = radius
+			report.Stable = true
+			break
 		}
+	}
+	if !report.Stable {
+		return 0, report, fmt.Errorf("distances did not become periodic within %d tiles of the start", MaxRadius)
+	}
+	radius := report.Radius
 
-		// North
-		if i-1 >= 0 {
-			nextCell := grid[i-1][j]
-			if nextCell.Type != "#" {
-				newSteps = append(newSteps, Step{Cell: nextCell})
+	count := 0
+	for tr := -radius; tr <= radius; tr++ {
+		for tc := -radius; tc <= radius; tc++ {
+			for r := 0; r < g.Rows; r++ {
+				for c := 0; c < g.Cols; c++ {
+					if reachable(f.At(tr, tc, r, c), steps) {
+						count++
+					}
+				}
 			}
 		}
-		// South
-		if i+1 < len(grid) {
-			nextCell := grid[i+1][j]
-			if nextCell.Type != "#" {
-				newSteps = append(newSteps, Step{Cell: nextCell})
+	}
+
+	for r := 0; r < g.Rows; r++ {
+		for c := 0; c < g.Cols; c++ {
+			// Bands running straight out from each side of the ring
+			for o := -radius; o <= radius; o++ {
+				for _, ref := range []struct {
+					D     int
+					Shift int
+				}{
+					{D: f.At(o, radius, r, c), Shift: g.Cols},
+					{D: f.At(o, -radius, r, c), Shift: g.Cols},
+					{D: f.At(radius, o, r, c), Shift: g.Rows},
+					{D: f.At(-radius, o, r, c), Shift: g.Rows},
+				} {
+					if ref.D != -1 {
+						count += bandCount(ref.D+ref.Shift, ref.Shift, steps)
+					}
+				}
 			}
-		}
-		// East
-		if j+1 < len(grid[0]) {
-			nextCell := grid[i][j+1]
-			if nextCell.Type != "#" {
-				newSteps = append(newSteps, Step{Cell: nextCell})
+
+			// Quadrants filling in diagonally beyond each corner of the ring
+			for _, d := range []int{
+				f.At(radius, radius, r, c),
+				f.At(radius, -radius, r, c),
+				f.At(-radius, radius, r, c),
+				f.At(-radius, -radius, r, c),
+			} {
+				if d == -1 {
+					continue
+				}
+				d += g.Rows + g.Cols
+				if g.Rows == g.Cols {
+					count += quadrantCount(d, g.Rows, steps)
+					continue
+				}
+				for ; d <= steps; d += g.Cols {
+					count += bandCount(d, g.Rows, steps)
+				}
 			}
 		}
-		// West
-		if j-1 >= 0 {
-			nextCell := grid[i][j-1]
-			if nextCell.Type != "#" {
-				newSteps = append(newSteps, Step{Cell: nextCell})
-			}
+	}
+
+	return count, report, nil
+}
+
+// reachable reports if a plot at distance d can be stood on after exactly steps steps
+func reachable(d, steps int) bool {
+	return d >= 0 && d <= steps && (steps-d)%2 == 0
+}
+
+// bandCount counts the k >= 0 where a plot at distance d + k*period is reachable
+func bandCount(d, period, steps int) int {
+	if d > steps {
+		return 0
+	}
+	n := (steps - d) / period
+	if period%2 == 0 {
+		if (steps-d)%2 != 0 {
+			return 0
 		}
+		return n + 1
+	}
+	// Odd periods flip parity every tile, so only every other k counts
+	q := (steps - d) % 2
+	if n < q {
+		return 0
 	}
+	return (n-q)/2 + 1
+}
 
-	// Remove duplicates, this could probably be more optimized to be a cache
-	newSteps = lo.UniqBy(newSteps, func(item Step) string {
-		return fmt.Sprintf("%d,%d", item.Cell.C, item.Cell.R)
-	})
+// quadrantCount counts reachable plots at distance d + k*period, where n+1 tiles in a quadrant share each k
+func quadrantCount(d, period, steps int) int {
+	if d > steps {
+		return 0
+	}
+	n := (steps - d) / period
+	if period%2 == 0 {
+		if (steps-d)%2 != 0 {
+			return 0
+		}
+		return (n + 1) * (n + 2) / 2
+	}
+	q := (steps - d) % 2
+	if n < q {
+		return 0
+	}
+	// Sum of k+1 over k = q, q+2, ..., q+2(m-1)
+	m := (n-q)/2 + 1
+	return m*q + m*(m-1) + m
+}
+
+// Assumptions are the properties of the input that ParseGrid and the distance fields rely on, checked before solving
+// ReachableInfinite doesn't need the square grid or clear start row and column, Check reports on those separately
+func Assumptions(input string) []ez.Assumption {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	return []ez.Assumption{
+		ez.Assume("every row is the same width", func() error {
+			for i, line := range lines {
+				if len(line) != len(lines[0]) {
+					return fmt.Errorf("row %d is %d wide, row 1 is %d", i+1, len(line), len(lines[0]))
+				}
+			}
+			return ez.Expectf(len(lines[0]) > 0, "the grid is empty")
+		}),
+		ez.Assume("the grid is only ., # and a single S", func() error {
+			if n := strings.Count(input, "S"); n != 1 {
+				return fmt.Errorf("found %d S", n)
+			}
+			for i, line := range lines {
+				if j := strings.IndexFunc(line, func(r rune) bool { return !strings.ContainsRune(".#S", r) }); j != -1 {
+					return fmt.Errorf("row %d column %d is %q", i+1, j+1, line[j])
+				}
+			}
+			return nil
+		}),
+	}
+}
 
-	return newSteps
+// Check inspects the grid for the properties the usual quadratic shortcut relies on
+func (g *Grid) Check() Report {
+	report := Report{
+		Square:        g.Rows == g.Cols,
+		StartCentered: g.Start.R == g.Rows/2 && g.Start.C == g.Cols/2 && g.Rows%2 == 1 && g.Cols%2 == 1,
+		ClearStartRow: true,
+		ClearStartCol: true,
+		ClearEdges:    true,
+	}
+	for c := 0; c < g.Cols; c++ {
+		report.ClearStartRow = report.ClearStartRow && !g.Rocks[g.Start.R*g.Cols+c]
+		report.ClearEdges = report.ClearEdges && !g.Rocks[c] && !g.Rocks[(g.Rows-1)*g.Cols+c]
+	}
+	for r := 0; r < g.Rows; r++ {
+		report.ClearStartCol = report.ClearStartCol && !g.Rocks[r*g.Cols+g.Start.C]
+		report.ClearEdges = report.ClearEdges && !g.Rocks[r*g.Cols] && !g.Rocks[r*g.Cols+g.Cols-1]
+	}
+	return report
+}
+
+// String lists each assumption and whether the input satisfied it
+func (r Report) String() string {
+	yes := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	shortcut := r.Square && r.StartCentered && r.ClearStartRow && r.ClearStartCol && r.ClearEdges
+	return strings.Join([]string{
+		"square grid: " + yes(r.Square),
+		"start centered: " + yes(r.StartCentered),
+		"start row clear: " + yes(r.ClearStartRow),
+		"start column clear: " + yes(r.ClearStartCol),
+		"edges clear: " + yes(r.ClearEdges),
+		"quadratic shortcut applies: " + yes(shortcut),
+		fmt.Sprintf("distances periodic: %s (radius %d)", yes(r.Stable), r.Radius),
+	}, "\n")
 }
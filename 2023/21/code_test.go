@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+const example = `...........
+.....###.#.
+.###.##..#.
+..#.#...#..
+....#.#....
+.##..S####.
+.##..#...#.
+.......##..
+.##.#.####.
+.##..##.##.
+...........`
+
+func TestReachable(t *testing.T) {
+	if got := ParseGrid(example).Reachable(6); got != 16 {
+		t.Errorf("Reachable(6) = %d, want 16", got)
+	}
+}
+
+// The step counts and answers given in the puzzle for the example garden repeating forever
+func TestReachableInfinite(t *testing.T) {
+	tests := []struct {
+		steps int
+		want  int
+	}{
+		{steps: 6, want: 16},
+		{steps: 10, want: 50},
+		{steps: 50, want: 1594},
+		{steps: 100, want: 6536},
+		{steps: 500, want: 167004},
+		{steps: 1000, want: 668697},
+		{steps: 5000, want: 16733044},
+	}
+	grid := ParseGrid(example)
+	for _, tt := range tests {
+		got, report, err := grid.ReachableInfinite(tt.steps)
+		if err != nil {
+			t.Errorf("ReachableInfinite(%d) error: %v\n%s", tt.steps, err, report)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ReachableInfinite(%d) = %d, want %d", tt.steps, got, tt.want)
+		}
+	}
+}
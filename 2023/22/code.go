@@ -2,17 +2,15 @@ package main
 
 import (
 	"aoc-in-go/ez"
-	"container/list"
 	"regexp"
 	"slices"
 	"strings"
 
 	"github.com/jpillora/puzzler/harness/aoc"
-	"github.com/samber/lo"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -31,234 +29,175 @@ type Cube struct {
 type Brick struct {
 	LineNo   int  // Used as a distinct label for a brick
 	Fallen   int  // Negative number of how much depth Z fell
-	Start    Cube // Only used for calculating "All", and is not updated during fall
-	End      Cube // Only used for calculating "All", and is not updated during fall
-	All      []Cube
+	Start    Cube // Lowest corner of the brick, updated as the brick falls
+	End      Cube // Highest corner of the brick, updated as the brick falls
 	Supports []*Brick
 	RestsOn  []*Brick
 }
 
+// BrickStats is the result of analysing a single settled brick
+type BrickStats struct {
+	LineNo        int
+	Supports      []int // LineNo of every brick resting directly on this one
+	RestsOn       []int // LineNo of every brick directly below this one, empty when on the ground
+	ChainReaction int   // How many other bricks fall if this one is disintegrated
+}
+
+// SafeToRemove reports whether the brick can be disintegrated without any others falling
+func (s BrickStats) SafeToRemove() bool {
+	return s.ChainReaction == 0
+}
+
 var reCube = regexp.MustCompile(`(\d+),(\d+),(\d+)~(\d+),(\d+),(\d+)`)
 
 func run(part2 bool, input string) any {
+	bricks := ParseBricks(input)
+	FallBricks(bricks)
+	stats := Analyze(bricks)
+
+	// Part 2
+	if part2 {
+		sum := 0
+		for _, s := range stats {
+			sum += s.ChainReaction
+		}
+		return sum
+	}
+
+	// Part 1
+	sum := 0
+	for _, s := range stats {
+		if s.SafeToRemove() {
+			sum++
+		}
+	}
+	return sum
+}
+
+// ParseBricks converts lines to Bricks, Start is always the lower corner on every axis
+func ParseBricks(input string) []*Brick {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
-	// Convert lines to Bricks
-	bricks := make([]*Brick, 0)
+	bricks := make([]*Brick, 0, len(lines))
 	for i, line := range lines {
+		ez.SetLine(i+1, line)
 		parts := reCube.FindStringSubmatch(line)
-		brick := Brick{
-			Fallen: 0,
+		a := Cube{X: ez.Atoi(parts[1]), Y: ez.Atoi(parts[2]), Z: ez.Atoi(parts[3])}
+		b := Cube{X: ez.Atoi(parts[4]), Y: ez.Atoi(parts[5]), Z: ez.Atoi(parts[6])}
+		bricks = append(bricks, &Brick{
 			LineNo: i + 1,
-			Start: Cube{
-				X: ez.Atoi(parts[1]),
-				Y: ez.Atoi(parts[2]),
-				Z: ez.Atoi(parts[3]),
-			},
-			End: Cube{
-				X: ez.Atoi(parts[4]),
-				Y: ez.Atoi(parts[5]),
-				Z: ez.Atoi(parts[6]),
-			},
-			All:      []Cube{},
-			Supports: make([]*Brick, 0),
-			RestsOn:  make([]*Brick, 0),
-		}
-		brick.SetAll()
-		bricks = append(bricks, &brick)
+			Start:  Cube{X: min(a.X, b.X), Y: min(a.Y, b.Y), Z: min(a.Z, b.Z)},
+			End:    Cube{X: max(a.X, b.X), Y: max(a.Y, b.Y), Z: max(a.Z, b.Z)},
+		})
 	}
+	return bricks
+}
 
-	// Sort bricks
+// FallBricks drops every brick until it lands on the ground or another brick, filling in Supports and RestsOn
+// The bricks are sorted lowest first, which is also an order where every brick comes after the bricks it rests on
+func FallBricks(bricks []*Brick) {
 	slices.SortStableFunc(bricks, func(a, b *Brick) int {
-		// Use Z to determine the depth of the bricks
-		// b - a to get a reverse sort, the lowest bricks will be at the end of the slice
-		if a.Start.Z != b.Start.Z {
-			return b.Start.Z - a.Start.Z
-		}
-		if a.End.Z != b.End.Z {
-			return b.End.Z - a.End.Z
-		}
-		return 0
+		return a.Start.Z - b.Start.Z
 	})
 
-	// Make them fall
-	FallBricks(bricks)
-
-	// Map of cube to brick will help us build the Supports and RestsOn slices later
-	cubeMap := make(map[Cube]*Brick, 0)
-	for i := 0; i < len(bricks); i++ {
-		b := *bricks[i]
-		for _, c := range b.All {
-			cubeMap[c] = bricks[i]
-		}
+	// Height map of the top brick in each X,Y column, bricks only ever land on top so this is all we need to settle them
+	type column struct {
+		Z     int
+		Brick *Brick
 	}
-
-	// Set supported and rests on per brick
-	for i := 0; i < len(bricks); i++ {
-		b := *bricks[i]
-		for _, c := range b.All {
-			// Look up the brick stack and determine which bricks support bricks above them
-			c := c
-			c.Z++
-			if bs, ok := cubeMap[c]; ok {
-				if bricks[i].LineNo != bs.LineNo { // Check to make sure we're not looking at ourself for vertical bricks
-					bricks[i].Supports = append(bricks[i].Supports, bs)
-				}
-			}
-			// Look down the brick stack and determine which bricks rest on bricks below them
-			// Using the same "c", so, we need to reduce it by 2, since we increased it by 1 above
-			c.Z -= 2
-			if bs, ok := cubeMap[c]; ok {
-				if bricks[i].LineNo != bs.LineNo { // Check to make sure we're not looking at ourself for vertical bricks
-					bricks[i].RestsOn = append(bricks[i].RestsOn, bs)
-				}
+	heights := make(map[[2]int]column)
+
+	for _, b := range bricks {
+		// The brick lands one above the highest column under its footprint
+		floor := 0
+		for x := b.Start.X; x <= b.End.X; x++ {
+			for y := b.Start.Y; y <= b.End.Y; y++ {
+				floor = max(floor, heights[[2]int{x, y}].Z)
 			}
 		}
-		// Make sure lists are unique, since support and rests on may have multiple touch points
-		bricks[i].Supports = lo.UniqBy(bricks[i].Supports, func(item *Brick) int { return item.LineNo })
-		bricks[i].RestsOn = lo.UniqBy(bricks[i].RestsOn, func(item *Brick) int { return item.LineNo })
-	}
-
-	// Part 2
-	if part2 {
-		sum := 0
-		for i := 0; i < len(bricks); i++ {
-			// Use a queue per brick to process "falling"
-			q := list.New()
-			// Falling is a list of bricks that are considered falling
-			falling := make([]*Brick, 0)
-			falling = append(falling, bricks[i])
-			// Start with the desired brick to destroy
-			q.PushBack(bricks[i])
 
-			for q.Len() > 0 {
-				bAny := q.Front()
-				b := bAny.Value.(*Brick)
-				// Check all bricks that current brick is supporting
-				for j := 0; j < len(b.Supports); j++ {
-					// Every brick the supported brick rests on must be falling to also be considered falling
-					if lo.Every(falling, b.Supports[j].RestsOn) {
-						q.PushBack(b.Supports[j])
-						falling = append(falling, b.Supports[j])
-					}
+		// Every distinct brick at that height is one this brick rests on
+		b.RestsOn = b.RestsOn[:0]
+		for x := b.Start.X; x <= b.End.X; x++ {
+			for y := b.Start.Y; y <= b.End.Y; y++ {
+				col := heights[[2]int{x, y}]
+				if col.Brick != nil && col.Z == floor && !slices.Contains(b.RestsOn, col.Brick) {
+					b.RestsOn = append(b.RestsOn, col.Brick)
+					col.Brick.Supports = append(col.Brick.Supports, b)
 				}
-				q.Remove(bAny)
 			}
-
-			// Uniq to account for multiple bricks supporting many bricks that each are considered as "falling"
-			// - 1 due to the destroyed brick doesn't actually fall
-			sum += len(lo.Uniq(falling)) - 1
 		}
 
-		return sum
-	}
+		drop := b.Start.Z - (floor + 1)
+		b.Fallen -= drop
+		b.Start.Z -= drop
+		b.End.Z -= drop
 
-	sum := 0
-	for i := 0; i < len(bricks); i++ {
-		b := *bricks[i]
-		// Any bricks that do not support other bricks are safe to destroy
-		if len(b.Supports) == 0 {
-			sum++
-			continue
-		} else {
-			// We can only elimiate bricks that support other bricks if the supported bricks are supported by 2 or more bricks
-			if lo.EveryBy(b.Supports, func(item *Brick) bool {
-				return len(item.RestsOn) >= 2
-			}) {
-				sum++
-				continue
+		for x := b.Start.X; x <= b.End.X; x++ {
+			for y := b.Start.Y; y <= b.End.Y; y++ {
+				heights[[2]int{x, y}] = column{Z: b.End.Z, Brick: b}
 			}
 		}
 	}
-
-	return sum
 }
 
-// FallBricks moves the All slice cubes down on the Z axis until they can no longer move
-func FallBricks(bricks []*Brick) {
-	// Taken is a cache of brick space that is occupied
-	taken := []Cube{}
-
-	// Start with the lowest brick
-	for i := len(bricks) - 1; i >= 0; i-- {
-		// Get the cubes of that brick
-		b := bricks[i]
-		cubes := make([]Cube, len(b.All))
-		copy(cubes, b.All)
-		for {
-			atLowest := false
-			//Check if the Z of any of the cubes are at 1, if so, it's already at it's lowest point
-			if !atLowest {
-				for _, cube := range cubes {
-					if cube.Z == 1 {
-						atLowest = true
-					}
-				}
-			}
-			// Attempt to lower all cubes Z by -1
-			if !atLowest {
-				testCubes := make([]Cube, len(cubes))
-				copy(testCubes, cubes)
-				for j := range testCubes {
-					testCubes[j].Z -= 1
-				}
-				if lo.Some(taken, testCubes) {
-					atLowest = true
-				} else {
-					cubes = testCubes
-				}
-			}
-			// If we're not at our lowest, we can update our cubes within the bricks
-			if !atLowest {
-				// Update the cubes of the brick
-				b.All = cubes
-				// Fallen could be used for adjusting the Z on the Start/End if it's needed
-				b.Fallen--
-			} else {
-				break
+// Analyze returns the stats for settled bricks, in the same order as bricks
+// A brick falls when every path from it down to the ground passes through the removed brick, ie. the removed brick dominates it.
+// Building the dominator tree rooted at the ground means the chain reaction for a brick is the size of its subtree
+func Analyze(bricks []*Brick) []BrickStats {
+	// Index 0 is the ground, bricks are 1..n in settled order
+	index := make(map[*Brick]int, len(bricks))
+	for i, b := range bricks {
+		index[b] = i + 1
+	}
+	idom := make([]int, len(bricks)+1)
+	depth := make([]int, len(bricks)+1)
+
+	// Lowest common ancestor of two nodes in the dominator tree built so far
+	lca := func(a, b int) int {
+		for a != b {
+			if depth[a] < depth[b] {
+				a, b = b, a
 			}
+			a = idom[a]
 		}
+		return a
+	}
 
-		// Cache taken cubes
-		for _, c := range cubes {
-			c := c
-			taken = append(taken, c)
+	// Settled order is topological, so everything a brick rests on already has its immediate dominator
+	for i, b := range bricks {
+		node := i + 1
+		dom := 0
+		if len(b.RestsOn) > 0 {
+			dom = index[b.RestsOn[0]]
+			for _, below := range b.RestsOn[1:] {
+				dom = lca(dom, index[below])
+			}
 		}
+		idom[node] = dom
+		depth[node] = depth[dom] + 1
 	}
-}
 
-// SetAll populates individual "cubes" in the b.All slice
-func (b *Brick) SetAll() {
-	// Always append the start
-	b.All = append(b.All, b.Start)
+	// Accumulate subtree sizes from the top down
+	size := make([]int, len(bricks)+1)
+	for node := len(bricks); node >= 1; node-- {
+		size[node]++
+		size[idom[node]] += size[node]
+	}
 
-	if b.Start.X != b.End.X {
-		// X increments
-		for x := b.Start.X + 1; x <= b.End.X; x++ {
-			b.All = append(b.All, Cube{
-				X: x,
-				Y: b.Start.Y,
-				Z: b.Start.Z,
-			})
+	stats := make([]BrickStats, len(bricks))
+	for i, b := range bricks {
+		stats[i] = BrickStats{
+			LineNo:        b.LineNo,
+			Supports:      make([]int, len(b.Supports)),
+			RestsOn:       make([]int, len(b.RestsOn)),
+			ChainReaction: size[i+1] - 1,
 		}
-	}
-	if b.Start.Y != b.End.Y {
-		// Y increments
-		for y := b.Start.Y + 1; y <= b.End.Y; y++ {
-			b.All = append(b.All, Cube{
-				X: b.Start.X,
-				Y: y,
-				Z: b.Start.Z,
-			})
+		for j, s := range b.Supports {
+			stats[i].Supports[j] = s.LineNo
 		}
-	}
-	if b.Start.Z != b.End.Z {
-		// Z increments
-		for z := b.Start.Z + 1; z <= b.End.Z; z++ {
-			b.All = append(b.All, Cube{
-				X: b.Start.X,
-				Y: b.Start.Y,
-				Z: z,
-			})
+		for j, r := range b.RestsOn {
+			stats[i].RestsOn[j] = r.LineNo
 		}
 	}
+	return stats
 }
@@ -1,16 +1,18 @@
 package main
 
 import (
-	"container/list"
+	"aoc-in-go/ez"
+	"math/bits"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 
-	dij "github.com/RyanCarrier/dijkstra"
 	"github.com/jpillora/puzzler/harness/aoc"
-	"github.com/samber/lo"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -20,312 +22,330 @@ func main() {
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
 
-type Path struct {
-	Cell Cell
-	From Cell
-	Dist int
+// Point is a row and column on the grid
+type Point struct {
+	R int
+	C int
 }
 
-type Cell struct {
-	ID   int
-	R    int
-	C    int
-	Type string
+// Directions in the same order as Slopes
+var Dirs = []Point{{R: -1}, {C: 1}, {R: 1}, {C: -1}}
+
+// Slopes are indexed by the direction they force you to walk in
+const Slopes = "^>v<"
+
+// Edge is a corridor between two junctions, Cells holds every cell walked in order, ending at the To junction
+type Edge struct {
+	To    int
+	Dist  int
+	Cells []Point
 }
 
-type Grid [][]Cell
+// Graph is the grid compressed down to its junctions, indexed densely so visited sets can be bitsets
+// Nodes[Start] and Nodes[End] are the openings in the first and last rows
+type Graph struct {
+	Nodes []Point
+	Edges [][]Edge
+	Start int
+	End   int
+}
 
 func run(part2 bool, input string) any {
-	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
-
-	var startCell *Cell
-	var endCell *Cell
-	g := dij.NewGraph()
-	// Map the grid to Cells
-	grid := make(Grid, len(lines))
-	id := 1
-	for i, line := range lines {
-		grid[i] = make([]Cell, len(line))
-		for j, char := range strings.Split(line, "") {
-			grid[i][j] = Cell{
-				ID:   id,
-				R:    i,
-				C:    j,
-				Type: char,
-			}
+	grid := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
 
-			// Start is the only . in the first row
-			if char == "." && i == 0 {
-				startCell = &grid[i][j]
-			}
-			// End is the only . in the last row
-			if char == "." && i == len(lines)-1 {
-				endCell = &grid[i][j]
-			}
+	// Part 2 doesn't care about slopes
+	g := BuildGraph(grid, !part2)
+	dist, _ := g.LongestPath(0)
+	return dist
+}
 
-			// Add an ID'd vertex to the graph for part 1
-			g.AddVertex(id)
-			id++
-		}
+// BuildGraph collects the start, end, and every cell with more than 2 exits as nodes, then walks each corridor between them
+// When slopes is true, slopes may only be walked downhill and corridors become one way
+func BuildGraph(grid []string, slopes bool) *Graph {
+	g := &Graph{}
+	index := make(map[Point]int)
+	addNode := func(p Point) {
+		index[p] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, p)
 	}
 
-	// Part 2
-	if part2 {
-		// Collect all junctions
-		junctions := make([]Cell, 0)
-		junctions = append(junctions, *startCell)
-		junctions = append(junctions, *endCell)
-
-		for i := 0; i < len(grid); i++ {
-			for j := 0; j < len(grid[i]); j++ {
-				if grid[i][j].IsJunction(grid) {
-					junctions = append(junctions, grid[i][j])
-				}
+	// Start is the only . in the first row, End is the only . in the last row
+	addNode(Point{R: 0, C: strings.Index(grid[0], ".")})
+	addNode(Point{R: len(grid) - 1, C: strings.Index(grid[len(grid)-1], ".")})
+	g.Start, g.End = 0, 1
+	for i := range grid {
+		for j := range grid[i] {
+			p := Point{R: i, C: j}
+			if grid[i][j] != '#' && len(openNeighbours(grid, p)) > 2 {
+				addNode(p)
 			}
 		}
+	}
 
-		// Get distance from junction to directly connected junctions
-		dg := make(map[Cell]map[Cell]int)
-		for n := 0; n < len(junctions); n++ {
-			dg[junctions[n]] = make(map[Cell]int)
-
-			q := list.New()
-			q.PushBack(Path{
-				Cell: junctions[n],
-				From: junctions[n],
-				Dist: 0,
-			})
-			for q.Len() > 0 {
-				pAny := q.Front()
-				p := pAny.Value.(Path)
-				c := p.Cell
-				i := c.R
-				j := c.C
-
-				// Exit condition, we've hit a junction, add to map
-				if c.IsJunction(grid) && c != p.From {
-					dg[junctions[n]][c] = p.Dist
-					q.Remove(pAny)
-					continue
-				}
-				// Exit condition, we've hit the desired end cell, add to map
-				if c == *endCell {
-					dg[junctions[n]][c] = p.Dist
-					q.Remove(pAny)
-					continue
-				}
-
-				// Variables that will be used later, xV = is that cell the one you came from?
-				var n, e, s, w Cell
-				var nV, eV, sV, wV bool
-				if i-1 >= 0 {
-					n = grid[i-1][j]
-					nV = (n.ID == p.From.ID)
-				}
-				if i+1 < len(grid) {
-					s = grid[i+1][j]
-					sV = (s.ID == p.From.ID)
-				}
-				if j-1 >= 0 {
-					w = grid[i][j-1]
-					wV = (w.ID == p.From.ID)
-				}
-				if j+1 < len(grid[i]) {
-					e = grid[i][j+1]
-					eV = (e.ID == p.From.ID)
-				}
-
-				// Part 2 doesn't care about slopes, just make sure it's on the grid and it's not a forest (#)
-				newCells := make([]Cell, 0)
-				if !nV && n.ID != 0 && n.Type != "#" {
-					newCells = append(newCells, n)
-				}
-				if !eV && e.ID != 0 && e.Type != "#" {
-					newCells = append(newCells, e)
+	g.Edges = make([][]Edge, len(g.Nodes))
+	for from, node := range g.Nodes {
+		for _, d := range openNeighbours(grid, node) {
+			if slopes && !canStep(grid, node, d) {
+				continue
+			}
+			// Follow the corridor until it reaches another junction, a dead end, or a slope going the wrong way
+			prev, cur := node, step(node, Dirs[d])
+			cells := []Point{cur}
+			for {
+				if to, ok := index[cur]; ok {
+					g.Edges[from] = append(g.Edges[from], Edge{To: to, Dist: len(cells), Cells: cells})
+					break
 				}
-				if !sV && s.ID != 0 && s.Type != "#" {
-					newCells = append(newCells, s)
+				next := -1
+				for _, nd := range openNeighbours(grid, cur) {
+					if step(cur, Dirs[nd]) != prev && (!slopes || canStep(grid, cur, nd)) {
+						next = nd
+					}
 				}
-				if !wV && w.ID != 0 && w.Type != "#" {
-					newCells = append(newCells, w)
+				if next == -1 {
+					break
 				}
+				prev, cur = cur, step(cur, Dirs[next])
+				cells = append(cells, cur)
+			}
+		}
+	}
 
-				for i := range newCells {
-					q.PushBack(Path{
-						Cell: newCells[i],
-						From: c,
-						Dist: p.Dist + 1,
-					})
-				}
+	return g
+}
 
-				q.Remove(pAny)
-			}
+// openNeighbours returns the directions from p that are on the grid and not forest (#)
+func openNeighbours(grid []string, p Point) []int {
+	dirs := make([]int, 0, 4)
+	for d, delta := range Dirs {
+		n := step(p, delta)
+		if n.R >= 0 && n.R < len(grid) && n.C >= 0 && n.C < len(grid[n.R]) && grid[n.R][n.C] != '#' {
+			dirs = append(dirs, d)
 		}
+	}
+	return dirs
+}
 
-		return LongestRoute(*startCell, *endCell, dg)
+// canStep reports if moving from p in direction d obeys the slopes, a slope must be left downhill and can't be entered uphill
+func canStep(grid []string, p Point, d int) bool {
+	if s := strings.IndexByte(Slopes, grid[p.R][p.C]); s != -1 && s != d {
+		return false
+	}
+	n := step(p, Dirs[d])
+	if s := strings.IndexByte(Slopes, grid[n.R][n.C]); s != -1 && s == (d+2)%4 {
+		return false
 	}
+	return true
+}
 
-	// Part 1
-	q := list.New()
-	visited := make([]Cell, 0)
-	q.PushBack(Path{
-		Cell: *startCell,
-		From: *startCell,
-		Dist: 0,
-	})
-	for q.Len() > 0 {
-		pAny := q.Front()
-		p := pAny.Value.(Path)
-		c := p.Cell
-		visited = append(visited, c)
-		i := c.R
-		j := c.C
-
-		// Variables that will be used later, xV = is that cell the one you came from?
-		var n, e, s, w Cell
-		var nV, eV, sV, wV bool
-		if i-1 >= 0 {
-			n = grid[i-1][j]
-			nV = (n.ID == p.From.ID)
-		}
-		if i+1 < len(grid) {
-			s = grid[i+1][j]
-			sV = (s.ID == p.From.ID)
-		}
-		if j-1 >= 0 {
-			w = grid[i][j-1]
-			wV = (w.ID == p.From.ID)
-		}
-		if j+1 < len(grid[i]) {
-			e = grid[i][j+1]
-			eV = (e.ID == p.From.ID)
-		}
+func step(p, delta Point) Point {
+	return Point{R: p.R + delta.R, C: p.C + delta.C}
+}
 
-		newCells := make([]Cell, 0)
-		switch c.Type {
-		case ".": // Any non forest direction that's not a slope pointing to it
-			if !nV && n.ID != 0 && n.Type != "#" && n.Type != "v" {
-				newCells = append(newCells, n)
-			}
-			if !eV && e.ID != 0 && e.Type != "#" && e.Type != "<" {
-				newCells = append(newCells, e)
-			}
-			if !sV && s.ID != 0 && s.Type != "#" && s.Type != "^" {
-				newCells = append(newCells, s)
-			}
-			if !wV && w.ID != 0 && w.Type != "#" && w.Type != ">" {
-				newCells = append(newCells, w)
-			}
-		case ">": // East only
-			if !eV && e.ID != 0 && e.Type != "#" && e.Type != "<" {
-				newCells = append(newCells, e)
-			}
-		case "^": // North only
-			if !nV && n.ID != 0 && n.Type != "#" && n.Type != "v" {
-				newCells = append(newCells, n)
-			}
-		case "v": // South only
-			if !sV && s.ID != 0 && s.Type != "#" && s.Type != "^" {
-				newCells = append(newCells, s)
-			}
-		case "<": // West only
-			if !wV && w.ID != 0 && w.Type != "#" && w.Type != ">" {
-				newCells = append(newCells, w)
-			}
-		}
+// Bitset is a fixed size set of node indexes
+type Bitset []uint64
 
-		for i := range newCells {
-			// Add path for any new cells to the graph
-			g.AddArc(c.ID, newCells[i].ID, 1)
+func NewBitset(n int) Bitset {
+	return make(Bitset, (n+63)/64)
+}
 
-			// Continue on if we've not visited the newCell
-			if !lo.Contains(visited, newCells[i]) {
-				q.PushBack(Path{
-					Cell: newCells[i],
-					From: c,
-					Dist: p.Dist + 1,
-				})
-			}
-		}
+func (b Bitset) Has(i int) bool {
+	return b[i/64]&(1<<(i%64)) != 0
+}
 
-		q.Remove(pAny)
+func (b Bitset) Set(i int) {
+	b[i/64] |= 1 << (i % 64)
+}
+
+func (b Bitset) Unset(i int) {
+	b[i/64] &^= 1 << (i % 64)
+}
+
+func (b Bitset) Count() int {
+	count := 0
+	for _, w := range b {
+		count += bits.OnesCount64(w)
 	}
+	return count
+}
 
-	// Get the longest
-	// ref: https://github.com/RyanCarrier/dijkstra
-	bests := lo.Must(g.Longest(startCell.ID, endCell.ID))
-	return bests.Distance
+func (b Bitset) Clone() Bitset {
+	return append(Bitset(nil), b...)
 }
 
-type Route struct {
-	Start   Cell
-	Visited []Cell
+// route is a partial walk through the graph, used both while searching and as a unit of parallel work
+type route struct {
+	Node    int
 	Dist    int
+	Bound   int // Upper bound on the distance still available, the sum of the best way into each unvisited node
+	Visited Bitset
+	Nodes   []int
 }
 
-// LongestRoute returns the maximum distance to without repeating a cell, from start to end
-// dg should be a map keyed by FromCell then ToCell, and the distance stored as the value
-func LongestRoute(start, end Cell, dg map[Cell]map[Cell]int) int {
-	dist := 0
-	visited := make([]Cell, 0)
-	visited = append(visited, start)
-
-	q := list.New()
-	q.PushBack(Route{
-		Start:   start,
-		Dist:    0,
-		Visited: visited,
-	})
-
-	for q.Len() > 0 {
-		rAny := q.Front()
-		r := rAny.Value.(Route)
-
-		for c, d := range dg[r.Start] {
-			if c == end {
-				// Exit condition, we've hit the desired end cell, set the max
-				dist = max(dist, r.Dist+d)
-				continue
+// searcher runs a depth first search from a route, pruning any branch that can't beat the best found by any searcher
+type searcher struct {
+	g         *Graph
+	maxIn     []int
+	best      *atomic.Int64
+	bestDist  int
+	bestNodes []int
+}
+
+func (s *searcher) dfs(r *route) {
+	if r.Node == s.g.End {
+		if r.Dist > s.bestDist {
+			s.bestDist = r.Dist
+			s.bestNodes = append(s.bestNodes[:0], r.Nodes...)
+		}
+		for {
+			cur := s.best.Load()
+			if int64(r.Dist) <= cur || s.best.CompareAndSwap(cur, int64(r.Dist)) {
+				break
 			}
+		}
+		return
+	}
+	if int64(r.Dist+r.Bound) <= s.best.Load() {
+		return
+	}
+
+	node, dist, bound := r.Node, r.Dist, r.Bound
+	for _, e := range s.g.Edges[node] {
+		if r.Visited.Has(e.To) {
+			continue
+		}
+		r.Visited.Set(e.To)
+		r.Nodes = append(r.Nodes, e.To)
+		r.Node, r.Dist, r.Bound = e.To, dist+e.Dist, bound-s.maxIn[e.To]
+		s.dfs(r)
+		r.Nodes = r.Nodes[:len(r.Nodes)-1]
+		r.Visited.Unset(e.To)
+	}
+	r.Node, r.Dist, r.Bound = node, dist, bound
+}
+
+// LongestPath returns the longest distance from Start to End without revisiting a node, and the nodes along that path
+// The search is split across workers goroutines (GOMAXPROCS when workers < 1), use 1 to search sequentially
+// The distance is always the same, when several paths tie for longest the one returned may vary between parallel runs
+func (g *Graph) LongestPath(workers int) (int, []int) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
 
-			if lo.Contains(r.Visited, c) {
-				// Exit condition, we've already visited the cell in our traversal
+	// Best way into each node, used to bound how much further any route could still go
+	maxIn := make([]int, len(g.Nodes))
+	for _, edges := range g.Edges {
+		for _, e := range edges {
+			maxIn[e.To] = max(maxIn[e.To], e.Dist)
+		}
+	}
+	start := route{
+		Node:    g.Start,
+		Bound:   -maxIn[g.Start],
+		Visited: NewBitset(len(g.Nodes)),
+		Nodes:   []int{g.Start},
+	}
+	for _, in := range maxIn {
+		start.Bound += in
+	}
+	start.Visited.Set(g.Start)
+
+	// Expand routes breadth first until there's enough work to keep every worker busy
+	work := []route{start}
+	for len(work) > 0 && len(work) < workers*8 {
+		next := make([]route, 0, len(work)*2)
+		expanded := false
+		for _, r := range work {
+			if r.Node == g.End {
+				next = append(next, r)
 				continue
 			}
-
-			// Copy the visited slice for each new route step we take
-			newVisited := make([]Cell, 0)
-			newVisited = append(newVisited, r.Visited...)
-			newVisited = append(newVisited, c)
-			q.PushBack(Route{
-				Start:   c,
-				Dist:    r.Dist + d,
-				Visited: newVisited,
-			})
+			for _, e := range g.Edges[r.Node] {
+				if r.Visited.Has(e.To) {
+					continue
+				}
+				expanded = true
+				visited := r.Visited.Clone()
+				visited.Set(e.To)
+				next = append(next, route{
+					Node:    e.To,
+					Dist:    r.Dist + e.Dist,
+					Bound:   r.Bound - maxIn[e.To],
+					Visited: visited,
+					Nodes:   append(append(make([]int, 0, len(g.Nodes)), r.Nodes...), e.To),
+				})
+			}
 		}
+		work = next
+		if !expanded {
+			break
+		}
+	}
 
-		q.Remove(rAny)
+	best := &atomic.Int64{}
+	best.Store(-1)
+	results := make([]*searcher, len(work))
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < min(workers, len(work)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				s := &searcher{g: g, maxIn: maxIn, best: best, bestDist: -1}
+				s.dfs(&work[i])
+				results[i] = s
+			}
+		}()
 	}
+	for i := range work {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
 
-	return dist
+	dist, nodes := 0, []int(nil)
+	for _, s := range results {
+		if s.bestDist > dist {
+			dist, nodes = s.bestDist, s.bestNodes
+		}
+	}
+	return dist, nodes
 }
 
-// IsJunction evaluates a cell on the grid to determine if it has more than 2 exits
-func (c Cell) IsJunction(g Grid) bool {
-	paths := 0
-	i := c.R
-	j := c.C
-	if i-1 >= 0 && g[i-1][j].Type != "#" {
-		paths++
+// Expand converts a path of node indexes back into every cell walked, including the start
+func (g *Graph) Expand(nodes []int) []Point {
+	if len(nodes) == 0 {
+		return nil
 	}
-	if i+1 < len(g) && g[i+1][j].Type != "#" {
-		paths++
+	cells := []Point{g.Nodes[nodes[0]]}
+	for i := 1; i < len(nodes); i++ {
+		for _, e := range g.Edges[nodes[i-1]] {
+			if e.To == nodes[i] {
+				cells = append(cells, e.Cells...)
+				break
+			}
+		}
 	}
-	if j-1 >= 0 && g[i][j-1].Type != "#" {
-		paths++
+	return cells
+}
+
+// Render draws the grid with every cell of the path marked as O, the start is left as S like the puzzle examples
+func Render(grid []string, path []Point) string {
+	out := make([][]byte, len(grid))
+	for i := range grid {
+		out[i] = []byte(grid[i])
 	}
-	if j+1 < len(g[i]) && g[i][j+1].Type != "#" {
-		paths++
+	for i, p := range path {
+		out[p.R][p.C] = 'O'
+		if i == 0 {
+			out[p.R][p.C] = 'S'
+		}
+	}
+
+	lines := make([]string, len(out))
+	for i := range out {
+		lines[i] = string(out[i])
 	}
-	return paths > 2
+	return strings.Join(lines, "\n")
 }
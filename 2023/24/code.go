@@ -1,7 +1,10 @@
 package main
 
 import (
+	"aoc-in-go/ez"
 	"errors"
+	"fmt"
+	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
@@ -11,7 +14,7 @@ import (
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -21,193 +24,233 @@ func main() {
 // 4. with: true (part2), and user input
 // the return value of each run is printed to stdout
 
-type Stone struct {
-	LineNo int
-	Point  Point
-	Line   Line
-	VX     float64
-	VY     float64
-	VZ     float64
-}
-
-type Point struct {
-	X float64
-	Y float64
-	Z float64
-}
-
-type Line struct {
-	Slope float64
-	Yint  float64
-}
-
-func CreateLine(a, b Point) Line {
-	slope := (b.Y - a.Y) / (b.X - a.X)
-	yint := a.Y - slope*a.X
-	return Line{slope, yint}
-}
-
-func EvalX(l Line, x float64) float64 {
-	return l.Slope*x + l.Yint
+// Vec is an exact integer position or velocity
+type Vec struct {
+	X int64
+	Y int64
+	Z int64
 }
 
-func Intersection(l1, l2 Line) (Point, error) {
-	if l1.Slope == l2.Slope {
-		return Point{}, errors.New("The lines do not intersect")
-	}
-	x := (l2.Yint - l1.Yint) / (l1.Slope - l2.Slope)
-	y := EvalX(l1, x)
-	return Point{X: x, Y: y}, nil
+type Stone struct {
+	LineNo int
+	Pos    Vec
+	Vel    Vec
 }
 
-var reStone = regexp.MustCompile(`(\d+),\s+(\d+),\s+(\d+)\s+@\s+([-]?\d+),\s+([-]?\d+),\s+([-]?\d+)`)
+var reStone = regexp.MustCompile(`(-?\d+),\s+(-?\d+),\s+(-?\d+)\s+@\s+(-?\d+),\s+(-?\d+),\s+(-?\d+)`)
 
 func run(part2 bool, input string) any {
 	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
 	stones := []Stone{}
 	for i, line := range lines {
+		ez.SetLine(i+1, line)
 		parts := reStone.FindStringSubmatch(line)
-		stone := Stone{
+		stones = append(stones, Stone{
 			LineNo: i + 1,
-			Point: Point{
-				X: lo.Must(strconv.ParseFloat(parts[1], 64)),
-				Y: lo.Must(strconv.ParseFloat(parts[2], 64)),
-				Z: lo.Must(strconv.ParseFloat(parts[3], 64)),
+			Pos: Vec{
+				X: lo.Must(strconv.ParseInt(parts[1], 10, 64)),
+				Y: lo.Must(strconv.ParseInt(parts[2], 10, 64)),
+				Z: lo.Must(strconv.ParseInt(parts[3], 10, 64)),
+			},
+			Vel: Vec{
+				X: lo.Must(strconv.ParseInt(parts[4], 10, 64)),
+				Y: lo.Must(strconv.ParseInt(parts[5], 10, 64)),
+				Z: lo.Must(strconv.ParseInt(parts[6], 10, 64)),
 			},
-			VX: lo.Must(strconv.ParseFloat(parts[4], 64)),
-			VY: lo.Must(strconv.ParseFloat(parts[5], 64)),
-			VZ: lo.Must(strconv.ParseFloat(parts[6], 64)),
-		}
-		stone.Line = CreateLine(stone.Point, Point{
-			X: stone.Point.X + stone.VX,
-			Y: stone.Point.Y + stone.VY,
 		})
-		stones = append(stones, stone)
 	}
 
 	// Part 2
-	// ref: https://github.com/rumkugel13/AdventOfCode2023/blob/main/day24.go
-	// Originally solved via Z3, but used the Go / Math based approach in the above to have a Go-only solution
 	if part2 {
-		if len(lines) < 20 {
-			// Example not supported
-			return 0
-		}
-
-		// Effectively, we're looking for stones that have matching velocities in X, Y, and Z, each evaluated independently
-		// These stones are travelling in parallel in that given direction and will help solve the coordinates
-		maybeX, maybeY, maybeZ := []int{}, []int{}, []int{}
-		for i := 0; i < len(stones)-1; i++ {
-			for j := i + 1; j < len(stones); j++ {
-				a, b := stones[i], stones[j]
-				if a.VX == b.VX {
-					nextMaybe := findMatchingVel(int(b.Point.X-a.Point.X), int(a.VX))
-					if len(maybeX) == 0 {
-						maybeX = nextMaybe
-					} else {
-						maybeX = lo.Intersect(maybeX, nextMaybe)
-					}
-				}
-				if a.VY == b.VY {
-					nextMaybe := findMatchingVel(int(b.Point.Y-a.Point.Y), int(a.VY))
-					if len(maybeY) == 0 {
-						maybeY = nextMaybe
-					} else {
-						maybeY = lo.Intersect(maybeY, nextMaybe)
-					}
-				}
-				if a.VZ == b.VZ {
-					nextMaybe := findMatchingVel(int(b.Point.Z-a.Point.Z), int(a.VZ))
-					if len(maybeZ) == 0 {
-						maybeZ = nextMaybe
-					} else {
-						maybeZ = lo.Intersect(maybeZ, nextMaybe)
-					}
-				}
-			}
+		rock, err := SolveRock(stones)
+		if err != nil {
+			return err
 		}
-
-		var out = 0
-		if len(maybeX) == len(maybeY) && len(maybeY) == len(maybeZ) && len(maybeZ) == 1 {
-			// only one possible velocity in all dimensions
-			// rockVel is a Point, just to capture the X, Y, and Z into a struct, it's not an actual point
-			rockVel := Point{X: float64(maybeX[0]), Y: float64(maybeY[0]), Z: float64(maybeZ[0])}
-			// The stones don't really matter, we just need two to calculate
-			A, B := stones[0], stones[1]
-			// Lots of math
-			// ref: https://www.reddit.com/r/adventofcode/comments/18pnycy/comment/keqf8uq/
-			mA := (A.VY - rockVel.Y) / (A.VX - rockVel.X)
-			mB := (B.VY - rockVel.Y) / (B.VX - rockVel.X)
-			cA := A.Point.Y - (mA * A.Point.X)
-			cB := B.Point.Y - (mB * B.Point.X)
-			xPos := (cB - cA) / (mA - mB)
-			yPos := mA*xPos + cA
-			t := (xPos - A.Point.X) / (A.VX - rockVel.X)
-			zPos := A.Point.Z + (A.VZ-rockVel.Z)*t
-			out = int(xPos + yPos + zPos)
-		}
-
-		return out
+		return rock.Pos.X + rock.Pos.Y + rock.Pos.Z
 	}
 
-	boxMin := float64(200000000000000)
-	boxMax := float64(400000000000000)
+	// Part 1
+	boxMin := int64(200000000000000)
+	boxMax := int64(400000000000000)
 	if len(lines) < 20 {
 		// Example data
-		boxMin = float64(7)
-		boxMax = float64(27)
+		boxMin = 7
+		boxMax = 27
 	}
+	return CountCrossings(stones, boxMin, boxMax)
+}
 
-	count := 0
+// Crossing finds where the X/Y paths of a and b cross, ignoring Z, returning the point and the time each stone gets there
+// Solving a.Pos + ta*a.Vel = b.Pos + tb*b.Vel by Cramer's rule, parallel paths have no single crossing and return false
+func Crossing(a, b Stone) (x, y, ta, tb *big.Rat, ok bool) {
+	det := a.Vel.Y*b.Vel.X - a.Vel.X*b.Vel.Y
+	if det == 0 {
+		return nil, nil, nil, nil, false
+	}
+	dx := big.NewInt(b.Pos.X - a.Pos.X)
+	dy := big.NewInt(b.Pos.Y - a.Pos.Y)
+	numA := new(big.Int).Sub(new(big.Int).Mul(big.NewInt(b.Vel.X), dy), new(big.Int).Mul(big.NewInt(b.Vel.Y), dx))
+	numB := new(big.Int).Sub(new(big.Int).Mul(big.NewInt(a.Vel.X), dy), new(big.Int).Mul(big.NewInt(a.Vel.Y), dx))
+	ta = new(big.Rat).SetFrac(numA, big.NewInt(det))
+	tb = new(big.Rat).SetFrac(numB, big.NewInt(det))
+
+	x = new(big.Rat).Mul(ta, new(big.Rat).SetInt64(a.Vel.X))
+	x.Add(x, new(big.Rat).SetInt64(a.Pos.X))
+	y = new(big.Rat).Mul(ta, new(big.Rat).SetInt64(a.Vel.Y))
+	y.Add(y, new(big.Rat).SetInt64(a.Pos.Y))
+	return x, y, ta, tb, true
+}
 
+// CountCrossings counts the pairs of stones whose X/Y paths cross in the future within the test area, edges included
+func CountCrossings(stones []Stone, boxMin, boxMax int64) int {
+	lower := new(big.Rat).SetInt64(boxMin)
+	upper := new(big.Rat).SetInt64(boxMax)
+	inBox := func(v *big.Rat) bool {
+		return v.Cmp(lower) >= 0 && v.Cmp(upper) <= 0
+	}
+
+	count := 0
 	for i := range stones {
-		a := stones[i]
 		for j := i + 1; j < len(stones); j++ {
-			b := stones[j]
-
-			p, err := Intersection(a.Line, b.Line)
-			if err != nil {
-				// Do not intersect
+			x, y, ta, tb, ok := Crossing(stones[i], stones[j])
+			// Skip parallel paths, and crossings either stone passed in the past
+			if !ok || ta.Sign() < 0 || tb.Sign() < 0 {
 				continue
 			}
-
-			if !(p.X >= boxMin && p.X <= boxMax && p.Y >= boxMin && p.Y <= boxMax) {
-				// Not within test area
-				continue
+			if inBox(x) && inBox(y) {
+				count++
 			}
+		}
+	}
+	return count
+}
 
-			// Check for "past" at intersection
-			if a.PointInFuture(p) && b.PointInFuture(p) {
-				count++
+// SolveRock finds the position and velocity of a rock thrown to hit every stone
+// The rock hits stone i when (P - p_i) x (V - v_i) = 0. Expanding it, P x V is the same for every stone, so taking the
+// difference between two stones leaves P x (v_j - v_i) + (p_j - p_i) x V = p_j x v_j - p_i x v_i, linear in P and V.
+// Two pairs of stones give 6 equations for the 6 unknowns, solved exactly with rationals and checked against every stone
+func SolveRock(stones []Stone) (Stone, error) {
+	if len(stones) < 3 {
+		return Stone{}, errors.New("need at least 3 stones to solve for the rock")
+	}
+
+	// Some triples of stones give a singular system, keep trying until one doesn't
+	for k := 2; k < len(stones); k++ {
+		rows := append(rockEquations(stones[0], stones[1]), rockEquations(stones[0], stones[k])...)
+		solution, ok := solve(rows)
+		if !ok {
+			continue
+		}
+
+		vals := make([]int64, len(solution))
+		for i, v := range solution {
+			if !v.IsInt() || !v.Num().IsInt64() {
+				return Stone{}, fmt.Errorf("rock solution is not whole, %s = %s", "PPPVVV"[i:i+1], v.RatString())
+			}
+			vals[i] = v.Num().Int64()
+		}
+		rock := Stone{
+			Pos: Vec{X: vals[0], Y: vals[1], Z: vals[2]},
+			Vel: Vec{X: vals[3], Y: vals[4], Z: vals[5]},
+		}
+		for _, s := range stones {
+			if !rock.Hits(s) {
+				return Stone{}, fmt.Errorf("rock %v @ %v misses the stone on line %d", rock.Pos, rock.Vel, s.LineNo)
 			}
 		}
+		return rock, nil
 	}
 
-	// solve part 1 here
-	return count
+	return Stone{}, errors.New("every choice of stones gave a singular system, no single rock found")
 }
 
-func (s Stone) PointInFuture(p Point) bool {
-	inFuture := true
-	if s.VX < 0 && p.X > s.Point.X {
-		inFuture = false
-	} else if s.VX > 0 && p.X < s.Point.X {
-		inFuture = false
+// Hits reports if the rock and stone are at the same place at the same non-negative time
+func (s Stone) Hits(other Stone) bool {
+	// The relative position must be parallel to the relative velocity, ie. their cross product is 0
+	dp := []*big.Int{big.NewInt(other.Pos.X - s.Pos.X), big.NewInt(other.Pos.Y - s.Pos.Y), big.NewInt(other.Pos.Z - s.Pos.Z)}
+	dv := []*big.Int{big.NewInt(s.Vel.X - other.Vel.X), big.NewInt(s.Vel.Y - other.Vel.Y), big.NewInt(s.Vel.Z - other.Vel.Z)}
+	for i := 0; i < 3; i++ {
+		a, b := (i+1)%3, (i+2)%3
+		if new(big.Int).Mul(dp[a], dv[b]).Cmp(new(big.Int).Mul(dp[b], dv[a])) != 0 {
+			return false
+		}
 	}
-	if s.VY < 0 && p.Y > s.Point.Y {
-		inFuture = false
-	} else if s.VY > 0 && p.Y < s.Point.Y {
-		inFuture = false
+	// And the time to close the gap can't be negative
+	for i := 0; i < 3; i++ {
+		if dv[i].Sign() != 0 {
+			return dp[i].Sign() == 0 || dp[i].Sign() == dv[i].Sign()
+		}
 	}
-	return inFuture
+	return dp[0].Sign() == 0 && dp[1].Sign() == 0 && dp[2].Sign() == 0
 }
 
-func findMatchingVel(dvel, pv int) []int {
-	match := []int{}
-	for v := -1000; v < 1000; v++ {
-		if v != pv && dvel%(v-pv) == 0 {
-			match = append(match, v)
+// rockEquations returns the 3 rows of P x w + u x V = rhs for the stones i and j, as coefficients of Px Py Pz Vx Vy Vz then rhs
+func rockEquations(i, j Stone) [][]*big.Rat {
+	w := Vec{X: j.Vel.X - i.Vel.X, Y: j.Vel.Y - i.Vel.Y, Z: j.Vel.Z - i.Vel.Z}
+	u := Vec{X: j.Pos.X - i.Pos.X, Y: j.Pos.Y - i.Pos.Y, Z: j.Pos.Z - i.Pos.Z}
+	ci, cj := cross(i.Pos, i.Vel), cross(j.Pos, j.Vel)
+
+	row := func(vals ...any) []*big.Rat {
+		out := make([]*big.Rat, len(vals))
+		for n, v := range vals {
+			switch v := v.(type) {
+			case int64:
+				out[n] = new(big.Rat).SetInt64(v)
+			case *big.Int:
+				out[n] = new(big.Rat).SetInt(v)
+			}
 		}
+		return out
+	}
+	return [][]*big.Rat{
+		row(int64(0), w.Z, -w.Y, int64(0), -u.Z, u.Y, new(big.Int).Sub(cj[0], ci[0])),
+		row(-w.Z, int64(0), w.X, u.Z, int64(0), -u.X, new(big.Int).Sub(cj[1], ci[1])),
+		row(w.Y, -w.X, int64(0), -u.Y, u.X, int64(0), new(big.Int).Sub(cj[2], ci[2])),
+	}
+}
+
+// cross returns a x b, using big ints as positions times velocities can overflow int64
+func cross(a, b Vec) []*big.Int {
+	mul := func(x, y int64) *big.Int {
+		return new(big.Int).Mul(big.NewInt(x), big.NewInt(y))
+	}
+	return []*big.Int{
+		new(big.Int).Sub(mul(a.Y, b.Z), mul(a.Z, b.Y)),
+		new(big.Int).Sub(mul(a.Z, b.X), mul(a.X, b.Z)),
+		new(big.Int).Sub(mul(a.X, b.Y), mul(a.Y, b.X)),
+	}
+}
+
+// solve runs Gauss-Jordan elimination on the augmented matrix rows, returning false if it is singular
+func solve(rows [][]*big.Rat) ([]*big.Rat, bool) {
+	n := len(rows)
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for r := col; r < n; r++ {
+			if rows[r][col].Sign() != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot == -1 {
+			return nil, false
+		}
+		rows[col], rows[pivot] = rows[pivot], rows[col]
+
+		for r := 0; r < n; r++ {
+			if r == col || rows[r][col].Sign() == 0 {
+				continue
+			}
+			factor := new(big.Rat).Quo(rows[r][col], rows[col][col])
+			for c := col; c <= n; c++ {
+				rows[r][c].Sub(rows[r][c], new(big.Rat).Mul(factor, rows[col][c]))
+			}
+		}
+	}
+
+	out := make([]*big.Rat, n)
+	for r := 0; r < n; r++ {
+		out[r] = new(big.Rat).Quo(rows[r][n], rows[r][r])
 	}
-	return match
+	return out, true
 }
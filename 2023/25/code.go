@@ -1,18 +1,15 @@
 package main
 
 import (
-	"container/list"
-	"math/rand"
-	"slices"
+	"aoc-in-go/ez"
+	"fmt"
 	"strings"
 
-	dij "github.com/RyanCarrier/dijkstra"
 	"github.com/jpillora/puzzler/harness/aoc"
-	"github.com/samber/lo"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -27,136 +24,169 @@ type Comp struct {
 	Label string
 }
 
+// Edge is a wire between two components by ID, Max and Min keep the direction from mattering
 type Edge struct {
 	Max int
 	Min int
 }
 
+// Graph holds the components indexed by ID and the wires between them
+type Graph struct {
+	Comps []Comp
+	Edges []Edge
+	// Adj holds the index into Edges of every wire connected to a component, indexed by ID
+	Adj [][]int
+}
+
+// Cut is a set of wires which splits the components into the two groups A and B
+type Cut struct {
+	Edges []Edge
+	A     []Comp
+	B     []Comp
+}
+
 func run(part2 bool, input string) any {
-	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	// No part 2 for day 25. Merry Christmas!
+	if part2 {
+		return "not implemented"
+	}
+
+	g := ParseGraph(input)
+	cut, err := g.MinCut(3)
+	if err != nil {
+		return err
+	}
 
-	// Just parsing to various maps and a graph for dijkstra
-	g := dij.NewGraph()
+	// solve
+	return len(cut.A) * len(cut.B)
+}
+
+// ParseGraph reads each line of connections, IDs are given out in the order components are first seen
+func ParseGraph(input string) *Graph {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	g := &Graph{}
 	ids := make(map[string]int)
-	comps := make(map[int]Comp)
-	conns := make(map[Comp]map[Comp]bool)
-	id := 1
-	for _, line := range lines {
-		parts := strings.Split(line, ": ")
-		a := Comp{
-			Label: parts[0],
-		}
-		if anId, ok := ids[a.Label]; !ok {
-			a.ID = id
-			g.AddVertex(id)
-			id++
-		} else {
-			a.ID = anId
-		}
-		ids[a.Label] = a.ID
-		comps[a.ID] = a
-		if _, ok := conns[a]; !ok {
-			conns[a] = make(map[Comp]bool)
+	comp := func(label string) int {
+		if id, ok := ids[label]; ok {
+			return id
 		}
+		id := len(g.Comps)
+		ids[label] = id
+		g.Comps = append(g.Comps, Comp{ID: id, Label: label})
+		g.Adj = append(g.Adj, nil)
+		return id
+	}
 
+	for i, line := range lines {
+		ez.SetLine(i+1, line)
+		parts := strings.Split(line, ": ")
+		a := comp(parts[0])
 		for _, con := range strings.Split(parts[1], " ") {
-			con := con
-			b := Comp{
-				Label: con,
-			}
-			if anId, ok := ids[b.Label]; !ok {
-				b.ID = id
-				g.AddVertex(id)
-				id++
-			} else {
-				b.ID = anId
-			}
-			ids[b.Label] = b.ID
-			comps[b.ID] = b
-			if _, ok := conns[b]; !ok {
-				conns[b] = make(map[Comp]bool)
-			}
-
+			b := comp(con)
 			// Connections, both ways
-			conns[a][b] = true
-			conns[b][a] = true
-			g.AddArc(a.ID, b.ID, 1)
-			g.AddArc(b.ID, a.ID, 1)
+			g.Adj[a] = append(g.Adj[a], len(g.Edges))
+			g.Adj[b] = append(g.Adj[b], len(g.Edges))
+			g.Edges = append(g.Edges, Edge{Max: max(a, b), Min: min(a, b)})
 		}
 	}
 
-	// No part 2 for day 25. Merry Christmas!
-	if part2 {
-		return "not implemented"
+	return g
+}
+
+// MinCut finds the wires to cut so the graph splits into two groups, verifying the smallest such cut has exactly k wires
+// Every wire has a capacity of 1, so the max flow between two components is the number of wires needed to separate them.
+// The global min cut separates component 0 from something, so checking the flow from 0 to every other component finds it exactly
+func (g *Graph) MinCut(k int) (*Cut, error) {
+	if len(g.Comps) < 2 {
+		return nil, fmt.Errorf("need at least 2 components to cut, have %d", len(g.Comps))
 	}
 
-	// Do random checks and record the edges that are crossed between the two points
-	// The most common 3 edges are what we're after, these are most likely going to be the three to cut
-	seen := make(map[Edge]int)
-	// n could be lower, but, 20,000 gives a very high confidence
-	// I was getting the same results at 1,000, but given the total node count > 1000, a few extra itterations isn't too bad
-	for n := 0; n < 20000; n++ {
-		aID := rand.Intn(len(ids)-2) + 1
-		bID := rand.Intn(len(ids)-2) + 1
-		for bID == aID {
-			// Just making sure a != b
-			bID = rand.Intn(len(ids)-2) + 1
+	// Flow along each wire, positive means Min -> Max
+	flow := make([]int, len(g.Edges))
+	// reachedK is one side of the first k wire cut found, every other pair must still be checked for a smaller cut
+	var reachedK []bool
+	for t := 1; t < len(g.Comps); t++ {
+		clear(flow)
+		// Augmenting more than k paths proves this pair can't be split by k wires, no need to keep going
+		n := 0
+		reached := g.augment(flow, 0, t)
+		for reached == nil && n <= k {
+			n++
+			reached = g.augment(flow, 0, t)
+		}
+		if n < k {
+			return nil, fmt.Errorf("min cut has %d wires, wanted %d: %s can be separated from %s with fewer", n, k, g.Comps[0].Label, g.Comps[t].Label)
 		}
-		short := lo.Must(g.Shortest(aID, bID))
-		// Store the edge
-		// Using min/max to make sure the direction of the path doesn't skew the results
-		for i := 0; i < len(short.Path)-1; i++ {
-			seen[Edge{
-				Max: max(short.Path[i], short.Path[i+1]),
-				Min: min(short.Path[i], short.Path[i+1]),
-			}]++
+		if n == k && reachedK == nil {
+			// Whatever could still be reached from 0 once the flow is saturated is one side of the cut
+			reachedK = reached
 		}
 	}
-
-	// Quick map[K]V -> []{K, V} so we can sort
-	entries := lo.Entries(seen)
-	slices.SortFunc(entries, func(a, b lo.Entry[Edge, int]) int {
-		return b.Value - a.Value
-	})
-
-	// Take the top 3
-	cuts := entries[:3]
-	//Break the connection of the offending nodes, in both directions
-	for _, cut := range cuts {
-		a := comps[cut.Key.Max]
-		b := comps[cut.Key.Min]
-		conns[a][b] = false
-		conns[b][a] = false
+	if reachedK == nil {
+		return nil, fmt.Errorf("no cut of %d wires exists, every split needs more", k)
 	}
-	// Get the connected nodes within each set
-	a := ConnectedNodes(conns, comps[cuts[0].Key.Max])
-	b := ConnectedNodes(conns, comps[cuts[0].Key.Min])
 
-	// solve
-	return len(a) * len(b)
+	cut := &Cut{}
+	for id, comp := range g.Comps {
+		if reachedK[id] {
+			cut.A = append(cut.A, comp)
+		} else {
+			cut.B = append(cut.B, comp)
+		}
+	}
+	for _, e := range g.Edges {
+		if reachedK[e.Min] != reachedK[e.Max] {
+			cut.Edges = append(cut.Edges, e)
+		}
+	}
+	if len(cut.Edges) != k {
+		return nil, fmt.Errorf("cut has %d wires, wanted %d", len(cut.Edges), k)
+	}
+	return cut, nil
 }
 
-// ConnectedNodes uses the connection map and a given start to determine which components are connected
-func ConnectedNodes(conns map[Comp]map[Comp]bool, start Comp) []Comp {
-	seen := []Comp{}
-
-	q := list.New()
-	q.PushBack(start)
-
-	for q.Len() > 0 {
-		aAny := q.Front()
-		a := aAny.Value.(Comp)
-		seen = append(seen, a)
-
-		// Get the connected nodes, and make sure the connection hasn't been disabled (t == false)
-		for b, t := range conns[a] {
-			if t && !lo.Contains(seen, b) {
-				q.PushBack(b)
+// augment searches breadth first for a path from s to t with spare capacity and pushes one unit of flow along it
+// It returns nil when a path was found, otherwise the set of components that could be reached from s
+func (g *Graph) augment(flow []int, s, t int) []bool {
+	seen := make([]bool, len(g.Comps))
+	via := make([]int, len(g.Comps))
+	seen[s] = true
+	q := []int{s}
+
+	for len(q) > 0 {
+		a := q[0]
+		q = q[1:]
+		if a == t {
+			break
+		}
+		for _, ei := range g.Adj[a] {
+			e := g.Edges[ei]
+			// Spare capacity depends on which way we're walking the wire
+			b, spare := e.Max, 1-flow[ei]
+			if a == e.Max {
+				b, spare = e.Min, 1+flow[ei]
+			}
+			if spare > 0 && !seen[b] {
+				seen[b] = true
+				via[b] = ei
+				q = append(q, b)
 			}
 		}
-
-		q.Remove(aAny)
+	}
+	if !seen[t] {
+		return seen
 	}
 
-	return lo.Uniq(seen)
+	// Walk back from t pushing flow along the path
+	for b := t; b != s; {
+		e := g.Edges[via[b]]
+		if b == e.Max {
+			flow[via[b]]++
+			b = e.Min
+		} else {
+			flow[via[b]]--
+			b = e.Max
+		}
+	}
+	return nil
 }
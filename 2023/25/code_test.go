@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+)
+
+const example = `jqt: rhn xhk nvd
+rsh: frs pzl lsr
+xhk: hfx
+cmg: qnr nvd lhk bvb
+rhn: xhk bvb hfx
+bvb: xhk hfx
+pzl: lsr hfx nvd
+qnr: nvd
+ntq: jqt hfx bvb xhk
+nvd: lhk
+lsr: lhk
+rzs: qnr cmg lsr rsh
+frs: qnr lhk lsr`
+
+func TestRunExample(t *testing.T) {
+	if got := run(false, example); got != 54 {
+		t.Errorf("part 1 = %v, want 54", got)
+	}
+}
+
+// a has exactly 3 wires, but p can be cut off with 2, which is only found after a's cut has been seen
+func TestMinCutChecksEveryPair(t *testing.T) {
+	g := ParseGraph("a: b c d\nb: c d\nc: d p\nd: p")
+	if cut, err := g.MinCut(3); err == nil {
+		t.Errorf("MinCut(3) = %v, want an error as a 2 wire cut exists", cut)
+	}
+	cut, err := g.MinCut(2)
+	if err != nil {
+		t.Fatalf("MinCut(2) error: %v", err)
+	}
+	if len(cut.Edges) != 2 || len(cut.A)*len(cut.B) != 4 {
+		t.Errorf("MinCut(2) = %d wires splitting %d/%d, want 2 wires splitting 4/1", len(cut.Edges), len(cut.A), len(cut.B))
+	}
+}
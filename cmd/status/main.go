@@ -0,0 +1,76 @@
+// Command status lists how far along each year's solutions are
+//
+//	go run ./cmd/status [YEAR]
+package main
+
+import (
+	"aoc-in-go/registry"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	if err := status(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// status prints a line per year, and a line per day too when a single year is asked for
+func status(args []string) error {
+	only := 0
+	if len(args) > 0 {
+		year, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("usage: status [YEAR], got %q", args[0])
+		}
+		only = year
+	}
+
+	years, err := registry.Scan(".")
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, y := range years {
+		if only != 0 && y.Year != only {
+			continue
+		}
+		found = true
+		stars, known := y.Stars()
+		fmt.Printf("%d: %2d days, %2d/%d parts implemented, %2d stars (%d days with a README)\n",
+			y.Year, len(y.Days), y.Implemented(), len(y.Days)*2, stars, known)
+		if only == 0 {
+			continue
+		}
+		for _, d := range y.Days {
+			fmt.Printf("  %02d  %s %s  %s\n", d.Day, part(d.Part1), part(d.Part2), starsOf(d))
+		}
+	}
+	if !found {
+		if only != 0 {
+			return fmt.Errorf("no solutions found for %d", only)
+		}
+		return fmt.Errorf("no solutions found, run from the repository root")
+	}
+	return nil
+}
+
+// part marks a part as implemented or still the template
+func part(done bool) string {
+	if done {
+		return "✓"
+	}
+	return "·"
+}
+
+// starsOf shows the day's stars, or ? without a README to read them from
+func starsOf(d registry.Day) string {
+	if !d.StarsKnown {
+		return "?"
+	}
+	return strings.Repeat("*", d.Stars)
+}
@@ -0,0 +1,94 @@
+package ez
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Assumption is a named property of the input that a solution relies on, Check returns nil when the input has it
+type Assumption struct {
+	Name  string
+	Check func() error
+}
+
+// Assume builds an Assumption, name should read as a statement about the input e.g. "every row is the same width"
+func Assume(name string, check func() error) Assumption {
+	return Assumption{Name: name, Check: check}
+}
+
+// Expectf returns nil when ok, otherwise an error explaining how the input differs, it keeps checks to one line
+func Expectf(ok bool, format string, a ...any) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf(format, a...)
+}
+
+// AssumptionResult is the outcome of a single check, Err is nil when it held
+type AssumptionResult struct {
+	Name string
+	Err  error
+}
+
+// AssumptionError is returned by CheckAssumptions when any assumption failed
+// Results holds every check in the order given, so the report shows what held as well as what didn't
+type AssumptionError struct {
+	Results []AssumptionResult
+}
+
+// Failed returns only the results of the assumptions that didn't hold
+func (e *AssumptionError) Failed() []AssumptionResult {
+	failed := []AssumptionResult{}
+	for _, r := range e.Results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+func (e *AssumptionError) Error() string {
+	lines := []string{fmt.Sprintf("%d of %d input assumptions failed:", len(e.Failed()), len(e.Results))}
+	for _, r := range e.Results {
+		if r.Err != nil {
+			lines = append(lines, fmt.Sprintf("  ✗ %s: %v", r.Name, r.Err))
+		} else {
+			lines = append(lines, "  ✓ "+r.Name)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap exposes each failure to errors.Is and errors.As
+func (e *AssumptionError) Unwrap() []error {
+	errs := []error{}
+	for _, r := range e.Failed() {
+		errs = append(errs, r.Err)
+	}
+	return errs
+}
+
+// CheckAssumptions runs every check, rather than stopping at the first failure, and returns an *AssumptionError
+// listing them all if any failed. A check that panics counts as failed, so a broken input can't crash the report
+func CheckAssumptions(assumptions ...Assumption) error {
+	results := make([]AssumptionResult, len(assumptions))
+	failed := false
+	for i, a := range assumptions {
+		results[i] = AssumptionResult{Name: a.Name, Err: runCheck(a.Check)}
+		failed = failed || results[i].Err != nil
+	}
+	if !failed {
+		return nil
+	}
+	return &AssumptionError{Results: results}
+}
+
+// runCheck calls check, turning a panic into an error
+func runCheck(check func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("check panicked: %v", r)
+		}
+	}()
+	return check()
+}
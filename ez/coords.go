@@ -0,0 +1,36 @@
+package ez
+
+import "slices"
+
+// ExpansionMap maps each index to where it ends up when every unoccupied index grows to factor indexes
+// A prefix sum of the unoccupied indexes means each lookup afterwards is O(1)
+func ExpansionMap(occupied []bool, factor int) []int {
+	out := make([]int, len(occupied))
+	empty := 0
+	for i, o := range occupied {
+		out[i] = i + empty*(factor-1)
+		if !o {
+			empty++
+		}
+	}
+	return out
+}
+
+// SumPairwiseDistances returns the sum of |a - b| over every unordered pair of values in O(n log n)
+// Once sorted, the value at i is the larger of the pair with each of the i values before it
+func SumPairwiseDistances(vals []int) int {
+	sorted := slices.Clone(vals)
+	slices.Sort(sorted)
+	sum := 0
+	prefix := 0
+	for i, v := range sorted {
+		sum += v*i - prefix
+		prefix += v
+	}
+	return sum
+}
+
+// SumManhattanDistances returns the sum of the Manhattan distances between every unordered pair of points given as xs[i], ys[i]
+func SumManhattanDistances(xs, ys []int) int {
+	return SumPairwiseDistances(xs) + SumPairwiseDistances(ys)
+}
@@ -0,0 +1,110 @@
+package ez
+
+// Aho–Corasick multi-pattern matching
+// https://en.wikipedia.org/wiki/Aho%E2%80%93Corasick_algorithm
+
+// Match is a single occurrence of a pattern, Pattern is its index in the patterns given to NewMatcher
+// and Start/End are the byte offsets it covers (End is exclusive)
+type Match struct {
+	Pattern int
+	Start   int
+	End     int
+}
+
+// Matcher finds every occurrence of a fixed set of patterns in a single pass over the input
+// Build it once with NewMatcher and reuse it, it is safe for concurrent use
+type Matcher struct {
+	patterns []string
+	// next is the full transition table, next[state][b] is the state after reading byte b
+	next [][256]int32
+	// out lists the patterns ending at each state, including those reached by following failure links
+	out [][]int
+}
+
+// NewMatcher builds a Matcher for the given patterns, empty patterns never match
+func NewMatcher(patterns ...string) *Matcher {
+	m := &Matcher{
+		patterns: patterns,
+		next:     make([][256]int32, 1),
+		out:      make([][]int, 1),
+	}
+
+	// Build the trie, -1 marks a missing edge until the failure links fill it in
+	for i := range m.next[0] {
+		m.next[0][i] = -1
+	}
+	for p, pattern := range patterns {
+		if len(pattern) == 0 {
+			continue
+		}
+		state := int32(0)
+		for i := 0; i < len(pattern); i++ {
+			if m.next[state][pattern[i]] == -1 {
+				m.next = append(m.next, [256]int32{})
+				m.out = append(m.out, nil)
+				for j := range m.next[len(m.next)-1] {
+					m.next[len(m.next)-1][j] = -1
+				}
+				m.next[state][pattern[i]] = int32(len(m.next) - 1)
+			}
+			state = m.next[state][pattern[i]]
+		}
+		m.out[state] = append(m.out[state], p)
+	}
+
+	// Breadth first, point each missing edge at where the longest proper suffix would go instead
+	fail := make([]int32, len(m.next))
+	queue := []int32{}
+	for b := 0; b < 256; b++ {
+		if child := m.next[0][b]; child == -1 {
+			m.next[0][b] = 0
+		} else {
+			queue = append(queue, child)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		m.out[state] = append(m.out[state], m.out[fail[state]]...)
+		for b := 0; b < 256; b++ {
+			child := m.next[state][b]
+			if child == -1 {
+				m.next[state][b] = m.next[fail[state]][b]
+				continue
+			}
+			fail[child] = m.next[fail[state]][b]
+			queue = append(queue, child)
+		}
+	}
+
+	return m
+}
+
+// Patterns returns the patterns the Matcher was built with
+func (m *Matcher) Patterns() []string {
+	return m.patterns
+}
+
+// FindAll returns every occurrence of every pattern in s, overlapping matches included
+// Matches are ordered by where they end, longest first when several end at the same byte
+func (m *Matcher) FindAll(s string) []Match {
+	matches := []Match{}
+	m.Scan(s, func(match Match) bool {
+		matches = append(matches, match)
+		return true
+	})
+	return matches
+}
+
+// Scan calls fn for each match in the same order as FindAll, stopping early if fn returns false
+func (m *Matcher) Scan(s string, fn func(Match) bool) {
+	state := int32(0)
+	for i := 0; i < len(s); i++ {
+		state = m.next[state][s[i]]
+		for _, p := range m.out[state] {
+			if !fn(Match{Pattern: p, Start: i + 1 - len(m.patterns[p]), End: i + 1}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package ez
+
+import (
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMatcherFindAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		input    string
+		want     []Match
+	}{
+		{
+			name:     "overlapping",
+			patterns: []string{"he", "she", "his", "hers"},
+			input:    "ushers",
+			want:     []Match{{Pattern: 1, Start: 1, End: 4}, {Pattern: 0, Start: 2, End: 4}, {Pattern: 3, Start: 2, End: 6}},
+		},
+		{
+			name:     "shared letters",
+			patterns: []string{"eight", "two"},
+			input:    "eightwo",
+			want:     []Match{{Pattern: 0, Start: 0, End: 5}, {Pattern: 1, Start: 4, End: 7}},
+		},
+		{
+			name:     "duplicates are both reported, in pattern order",
+			patterns: []string{"ab", "ab"},
+			input:    "xab",
+			want:     []Match{{Pattern: 0, Start: 1, End: 3}, {Pattern: 1, Start: 1, End: 3}},
+		},
+		{
+			name:     "longest first when ending at the same byte",
+			patterns: []string{"a", "aa", "aaa"},
+			input:    "aaa",
+			want: []Match{
+				{Pattern: 0, Start: 0, End: 1},
+				{Pattern: 1, Start: 0, End: 2}, {Pattern: 0, Start: 1, End: 2},
+				{Pattern: 2, Start: 0, End: 3}, {Pattern: 1, Start: 1, End: 3}, {Pattern: 0, Start: 2, End: 3},
+			},
+		},
+		{
+			name:     "empty patterns never match",
+			patterns: []string{"", "b"},
+			input:    "abc",
+			want:     []Match{{Pattern: 1, Start: 1, End: 2}},
+		},
+		{
+			name:     "no match",
+			patterns: []string{"xyz"},
+			input:    "xyxy",
+			want:     []Match{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewMatcher(tt.patterns...).FindAll(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindAll(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherScanStops(t *testing.T) {
+	calls := 0
+	NewMatcher("a").Scan("aaaa", func(Match) bool {
+		calls++
+		return calls < 2
+	})
+	if calls != 2 {
+		t.Errorf("Scan called fn %d times after returning false, want 2", calls)
+	}
+}
+
+// digitWords are the day 1 tokens, the value of pattern p is p%9 + 1
+var digitWords = []string{
+	"one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+	"1", "2", "3", "4", "5", "6", "7", "8", "9",
+}
+
+// calibrationLines generates day 1 style lines, letters with digits and spelled out digits mixed in
+func calibrationLines(n int) []string {
+	r := rand.New(rand.NewSource(1))
+	lines := make([]string, n)
+	for i := range lines {
+		b := strings.Builder{}
+		for b.Len() < 40 {
+			switch r.Intn(4) {
+			case 0:
+				b.WriteString(digitWords[r.Intn(len(digitWords))])
+			default:
+				b.WriteByte(byte('a' + r.Intn(26)))
+			}
+		}
+		b.WriteString(digitWords[9+r.Intn(9)])
+		lines[i] = b.String()
+	}
+	return lines
+}
+
+// sumMatcher sums the first and last digit of each line using a Matcher
+func sumMatcher(m *Matcher, lines []string) int {
+	sum := 0
+	for _, line := range lines {
+		matches := m.FindAll(line)
+		sum += (matches[0].Pattern%9+1)*10 + matches[len(matches)-1].Pattern%9 + 1
+	}
+	return sum
+}
+
+var (
+	numWordsRx = regexp.MustCompile(`(one|two|three|four|five|six|seven|eight|nine)`)
+	nonDigits  = regexp.MustCompile(`\D`)
+	// Keep the first and last letters, so words sharing a letter e.g. eightwo both still match
+	numWords = map[string]string{
+		"one": "o1e", "two": "t2o", "three": "t3e", "four": "4", "five": "5e",
+		"six": "6", "seven": "7n", "eight": "e8t", "nine": "n9e",
+	}
+)
+
+// sumRegexp sums the first and last digit of each line the way day 1 originally did, with the regexps compiled once
+func sumRegexp(lines []string) int {
+	sum := 0
+	for _, line := range lines {
+		for {
+			word := numWordsRx.FindString(line)
+			if word == "" {
+				break
+			}
+			line = strings.Replace(line, word, numWords[word], 1)
+		}
+		nums := nonDigits.ReplaceAllString(line, "")
+		sum += Atoi(nums[0:1])*10 + Atoi(nums[len(nums)-1:])
+	}
+	return sum
+}
+
+func TestMatcherAgreesWithRegexp(t *testing.T) {
+	lines := append(calibrationLines(1000), "two1nine", "eightwothree", "abcone2threexyz", "xtwone3four", "4nineeightseven2", "zoneight234", "7pqrstsixteen")
+	if got, want := sumMatcher(NewMatcher(digitWords...), lines), sumRegexp(lines); got != want {
+		t.Errorf("matcher sum = %d, regexp sum = %d", got, want)
+	}
+}
+
+func BenchmarkMatcher(b *testing.B) {
+	lines := calibrationLines(1000)
+	m := NewMatcher(digitWords...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sumMatcher(m, lines)
+	}
+}
+
+func BenchmarkRegexp(b *testing.B) {
+	lines := calibrationLines(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sumRegexp(lines)
+	}
+}
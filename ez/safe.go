@@ -0,0 +1,93 @@
+package ez
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/maruel/panicparse/v2/stack"
+)
+
+// current is the input line the running solution last registered with SetLine
+var current struct {
+	sync.Mutex
+	no   int
+	line string
+}
+
+// SetLine registers the input line being processed, numbered from 1, so a panic can say where in the input it happened
+// It is cheap enough to call for every line, and safe to call from worker goroutines
+func SetLine(no int, line string) {
+	current.Lock()
+	current.no, current.line = no, line
+	current.Unlock()
+}
+
+// PanicError is a recovered panic, along with the last input line registered and the stack trimmed to this module
+type PanicError struct {
+	Value  any
+	LineNo int
+	Line   string
+	Stack  []string
+}
+
+func (e *PanicError) Error() string {
+	lines := []string{fmt.Sprintf("panic: %v", e.Value)}
+	if e.LineNo > 0 {
+		lines = append(lines, fmt.Sprintf("last input line registered: %d: %q", e.LineNo, e.Line))
+	}
+	for _, frame := range e.Stack {
+		lines = append(lines, "\t"+frame)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap exposes the panic value to errors.Is and errors.As when it was an error, like a runtime.Error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Safe wraps run so a panic ends only the run it happened in, it is returned as a *PanicError for the harness to print
+// and the next input still runs. Use it as aoc.Harness(ez.Safe(run))
+// A panic in a goroutine started by run can't be recovered here, and still crashes the whole program
+func Safe(run func(part2 bool, input string) any) func(part2 bool, input string) any {
+	return func(part2 bool, input string) (out any) {
+		SetLine(0, "")
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			current.Lock()
+			no, line := current.no, current.line
+			current.Unlock()
+			// Still inside the deferred call, so the stack includes the frames that panicked
+			out = &PanicError{Value: r, LineNo: no, Line: line, Stack: trimmedStack()}
+		}()
+		return run(part2, input)
+	}
+}
+
+// trimmedStack returns the current goroutine's stack, leaf first, keeping only frames from this module
+// Runtime and harness frames, and Safe's own, only get in the way of finding the line that panicked
+func trimmedStack() []string {
+	buf := make([]byte, 64<<10)
+	buf = buf[:runtime.Stack(buf, false)]
+	snapshot, _, err := stack.ScanSnapshot(bytes.NewReader(buf), &bytes.Buffer{}, stack.DefaultOpts())
+	if snapshot == nil || len(snapshot.Goroutines) == 0 {
+		return []string{fmt.Sprintf("(stack unavailable: %v)", err)}
+	}
+
+	frames := []string{}
+	for _, call := range snapshot.Goroutines[0].Stack.Calls {
+		ours := call.Func.IsPkgMain || strings.HasPrefix(call.Func.ImportPath, "aoc-in-go/")
+		if !ours || call.Func.ImportPath == "aoc-in-go/ez" && call.SrcName == "safe.go" {
+			continue
+		}
+		frames = append(frames, fmt.Sprintf("%s.%s %s:%d", call.Func.DirName, call.Func.Name, call.DirSrc, call.Line))
+	}
+	return frames
+}
@@ -0,0 +1,61 @@
+package ez
+
+import "strings"
+
+// Vocabulary maps each token to the value it stands for
+type Vocabulary map[string]int
+
+// Digits are the single characters 0 through 9
+var Digits = Vocabulary{
+	"0": 0, "1": 1, "2": 2, "3": 3, "4": 4,
+	"5": 5, "6": 6, "7": 7, "8": 8, "9": 9,
+}
+
+// EnglishDigits are the spelled out numbers one through nine
+var EnglishDigits = Vocabulary{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9,
+}
+
+// Merge returns a new Vocabulary holding every token of v and others, later vocabularies win on duplicate tokens
+func (v Vocabulary) Merge(others ...Vocabulary) Vocabulary {
+	out := Vocabulary{}
+	for _, vocab := range append([]Vocabulary{v}, others...) {
+		for token, value := range vocab {
+			out[token] = value
+		}
+	}
+	return out
+}
+
+// match returns the value of the longest token starting at i
+func (v Vocabulary) match(s string, i int) (int, bool) {
+	best, value := 0, 0
+	for token, val := range v {
+		if len(token) > best && strings.HasPrefix(s[i:], token) {
+			best, value = len(token), val
+		}
+	}
+	return value, best > 0
+}
+
+// FirstToken scans forward for the earliest token in s and returns its value
+// Tokens may overlap, eg. "eightwo" has "eight" first and "two" last
+func (v Vocabulary) FirstToken(s string) (int, bool) {
+	for i := 0; i < len(s); i++ {
+		if value, ok := v.match(s, i); ok {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// LastToken scans backward for the token starting latest in s and returns its value
+func (v Vocabulary) LastToken(s string) (int, bool) {
+	for i := len(s) - 1; i >= 0; i-- {
+		if value, ok := v.match(s, i); ok {
+			return value, true
+		}
+	}
+	return 0, false
+}
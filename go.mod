@@ -9,13 +9,12 @@ require golang.org/x/exp v0.0.0-20231206192017-f3f8817b8deb
 require (
 	github.com/JohannesKaufmann/html-to-markdown v1.4.2 // indirect
 	github.com/PuerkitoBio/goquery v1.8.1 // indirect
-	github.com/RyanCarrier/dijkstra v1.3.0
 	github.com/andybalholm/cascadia v1.3.2 // indirect
 	github.com/emirpasic/gods v1.18.1
 	github.com/fsnotify/fsnotify v1.7.0 // indirect
 	github.com/jpillora/ansi v1.0.3 // indirect
 	github.com/jpillora/maplock v0.0.0-20160420012925-5c725ac6e22a // indirect
-	github.com/maruel/panicparse/v2 v2.3.1 // indirect
+	github.com/maruel/panicparse/v2 v2.3.1
 	github.com/samber/lo v1.39.0
 	golang.org/x/net v0.19.0 // indirect
 	golang.org/x/sync v0.5.0 // indirect
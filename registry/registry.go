@@ -0,0 +1,139 @@
+// Package registry finds every solution in the repository, across all years, and how far along each one is
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Day is a single puzzle's solution directory, <year>/<day>/code.go
+type Day struct {
+	Year int
+	Day  int
+	Dir  string
+	// Stars is how many answers README.md records as accepted, only meaningful when StarsKnown is true
+	Stars      int
+	StarsKnown bool
+	// Part1 and Part2 are false while code.go still has that part of the run.sh template in it
+	Part1 bool
+	Part2 bool
+}
+
+// Year is every day found for a single event, in day order
+type Year struct {
+	Year int
+	Days []Day
+}
+
+// Stars totals the stars of the days with a README, along with how many days had one
+func (y Year) Stars() (stars, known int) {
+	for _, d := range y.Days {
+		if d.StarsKnown {
+			stars += d.Stars
+			known++
+		}
+	}
+	return stars, known
+}
+
+// Implemented counts the parts no longer using the run.sh template
+func (y Year) Implemented() int {
+	n := 0
+	for _, d := range y.Days {
+		if d.Part1 {
+			n++
+		}
+		if d.Part2 {
+			n++
+		}
+	}
+	return n
+}
+
+// The template run.sh writes for a new day, a part is implemented once its placeholder is gone
+const (
+	part1Placeholder = "// solve part 1 here\n\treturn 42"
+	part2Placeholder = `return "not implemented"`
+)
+
+// answerMarker is what the downloaded puzzle text says once a part's answer has been accepted
+const answerMarker = "Your puzzle answer was"
+
+// Scan walks root for <year>/<day>/code.go, directories that aren't a 4 digit year or a 1-25 day are skipped,
+// so shared packages like ez and 2019/intcode live alongside the solutions without being counted
+func Scan(root string) ([]Year, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	years := []Year{}
+	for _, entry := range entries {
+		year, ok := number(entry, 4, 2015, 9999)
+		if !ok {
+			continue
+		}
+		days, err := scanYear(filepath.Join(root, entry.Name()), year)
+		if err != nil {
+			return nil, err
+		}
+		if len(days) > 0 {
+			years = append(years, Year{Year: year, Days: days})
+		}
+	}
+	sort.Slice(years, func(i, j int) bool { return years[i].Year < years[j].Year })
+	return years, nil
+}
+
+// scanYear reads every day directory within a year directory
+func scanYear(dir string, year int) ([]Day, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	days := []Day{}
+	for _, entry := range entries {
+		day, ok := number(entry, 2, 1, 25)
+		if !ok {
+			continue
+		}
+		d := Day{Year: year, Day: day, Dir: filepath.Join(dir, entry.Name())}
+
+		code, err := os.ReadFile(filepath.Join(d.Dir, "code.go"))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		src := strings.ReplaceAll(string(code), "\r\n", "\n")
+		d.Part1 = !strings.Contains(src, part1Placeholder)
+		d.Part2 = !strings.Contains(src, part2Placeholder)
+
+		readme, err := os.ReadFile(filepath.Join(d.Dir, "README.md"))
+		if err == nil {
+			d.Stars = min(strings.Count(string(readme), answerMarker), 2)
+			d.StarsKnown = true
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day < days[j].Day })
+	return days, nil
+}
+
+// number parses a directory name of exactly width digits within lo..hi
+func number(entry os.DirEntry, width, lo, hi int) (int, bool) {
+	if !entry.IsDir() || len(entry.Name()) != width {
+		return 0, false
+	}
+	n, err := strconv.Atoi(entry.Name())
+	if err != nil || n < lo || n > hi {
+		return 0, false
+	}
+	return n, true
+}
@@ -11,11 +11,13 @@ function template() {
 package main
 
 import (
+	"aoc-in-go/ez"
+
 	"github.com/jpillora/puzzler/harness/aoc"
 )
 
 func main() {
-	aoc.Harness(run)
+	aoc.Harness(ez.Safe(run))
 }
 
 // on code change, run will be executed 4 times:
@@ -35,11 +37,17 @@ func run(part2 bool, input string) any {
 EOF
 }
 
+# status [YEAR] lists completion per year
+if [ "${1:-}" == "status" ]; then
+	go run ./cmd/status ${2:-}
+	exit $?
+fi
 # two args YEAR and DAY
 YEAR="${1:-}"
 DAY="${2:-}"
 if [ -z "$YEAR" ] || [ -z "$DAY" ]; then
 	echo "Usage: $0 <YEAR> <DAY>"
+	echo "       $0 status [YEAR]"
 	exit 1
 fi
 # pad DAY to 2 digits